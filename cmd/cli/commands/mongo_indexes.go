@@ -2,6 +2,15 @@ package commands
 
 import (
 	"context"
+	"whereiseveryone/internal/apikeys"
+	"whereiseveryone/internal/attempts"
+	"whereiseveryone/internal/friendrequests"
+	"whereiseveryone/internal/groups"
+	"whereiseveryone/internal/links"
+	"whereiseveryone/internal/oidcstates"
+	"whereiseveryone/internal/resets"
+	"whereiseveryone/internal/shares"
+	"whereiseveryone/internal/tokens"
 	"whereiseveryone/internal/users"
 )
 
@@ -9,9 +18,63 @@ func (c *commandApp) mongoIndexes(ctx context.Context) {
 	envHandler := c.mustGetEnvHandler()
 	mongoCollections := c.mustGetMongoCollections(ctx, envHandler)
 
-	usersAdapter := users.NewMongoAdapter(mongoCollections.Users, c.timer, c.logger)
+	usersAdapter := users.NewMongoAdapter(mongoCollections.Users, c.timer, c.logger, 0)
 
 	if err := usersAdapter.EnsureIndexes(c.Context()); err != nil {
 		c.logger.Fatalf("create indexes on users collection: %s", err.Error())
 	}
+
+	revokedTokensAdapter := tokens.NewMongoAdapter(mongoCollections.RevokedTokens, c.timer, c.logger, 0)
+
+	if err := revokedTokensAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on revoked_tokens collection: %s", err.Error())
+	}
+
+	resetsAdapter := resets.NewMongoAdapter(mongoCollections.PasswordResets, c.timer, c.logger)
+
+	if err := resetsAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on password_resets collection: %s", err.Error())
+	}
+
+	attemptsAdapter := attempts.NewMongoAdapter(mongoCollections.LoginAttempts, c.timer, c.logger)
+
+	if err := attemptsAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on login_attempts collection: %s", err.Error())
+	}
+
+	oidcStatesAdapter := oidcstates.NewMongoAdapter(mongoCollections.OIDCStates, c.timer, c.logger)
+
+	if err := oidcStatesAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on oidc_states collection: %s", err.Error())
+	}
+
+	apiKeysAdapter := apikeys.NewMongoAdapter(mongoCollections.APIKeys, c.timer, c.logger)
+
+	if err := apiKeysAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on api_keys collection: %s", err.Error())
+	}
+
+	friendRequestsAdapter := friendrequests.NewMongoAdapter(mongoCollections.FriendRequests, c.timer, c.logger)
+
+	if err := friendRequestsAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on friend_requests collection: %s", err.Error())
+	}
+
+	groupsAdapter := groups.NewMongoAdapter(mongoCollections.Groups, c.timer, c.logger)
+
+	if err := groupsAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on groups collection: %s", err.Error())
+	}
+
+	sharesAdapter := shares.NewMongoAdapter(mongoCollections.LocationShares, c.timer, c.logger)
+
+	if err := sharesAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on location_shares collection: %s", err.Error())
+	}
+
+	linksAdapter := links.NewMongoAdapter(mongoCollections.ShareLinks, c.timer, c.logger)
+
+	if err := linksAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("create indexes on share_links collection: %s", err.Error())
+	}
 }
@@ -4,20 +4,39 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 	"whereiseveryone/internal/config"
 
 	"github.com/go-playground/validator"
+	"whereiseveryone/internal/apikeys"
+	"whereiseveryone/internal/attempts"
+	"whereiseveryone/internal/friendrequests"
+	"whereiseveryone/internal/groups"
+	"whereiseveryone/internal/links"
 	"whereiseveryone/internal/mongo"
+	"whereiseveryone/internal/oidcstates"
+	"whereiseveryone/internal/resets"
+	"whereiseveryone/internal/shares"
+	"whereiseveryone/internal/tokens"
 	"whereiseveryone/internal/users"
 	"whereiseveryone/internal/webapi"
 	authMux "whereiseveryone/internal/webapi/auth"
+	groupsMux "whereiseveryone/internal/webapi/groups"
 	meMux "whereiseveryone/internal/webapi/me"
+	publicMux "whereiseveryone/internal/webapi/public"
+	"whereiseveryone/pkg/crypto"
 	"whereiseveryone/pkg/env"
 	"whereiseveryone/pkg/jwt"
 	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/mail"
+	"whereiseveryone/pkg/oidc"
 	"whereiseveryone/pkg/timer"
 
 	_ "github.com/swaggo/echo-swagger" // echo-swagger middleware
@@ -35,8 +54,14 @@ import (
 // @in header
 // @name Authorization
 
+// @securityDefinitions.apikey ApiKey
+// @in header
+// @name X-Api-Key
+
 // @BasePath /api
 
+const defaultReservedUsernames = "admin,administrator,root,system,support,help,moderator,staff,api,me,whereiseveryone"
+
 func main() {
 	// Flags
 	configPathFlag := flag.String("config", "./.env/local.json", "config path")
@@ -63,15 +88,94 @@ func main() {
 		panic(err)
 	}
 	defer mongoCollections.Disconnect(appCtx)
-	usersAdapter := users.NewMongoAdapter(mongoCollections.Users, utcTimer, log)
 
 	// Echo
-	jwtSecret := envHandler.MustEnv(config.ConfJwtSecret)
-	// TODO: Get VALIDITY from config
-	jwtInstance := jwt.NewJWT(utcTimer, []byte(jwtSecret), time.Duration(168)*time.Hour)
+	jwtKeys, err := loadJWTKeys(envHandler)
+	if err != nil {
+		log.Fatalf("loading jwt keys: %s", err.Error())
+	}
+	jwtConfig := jwt.Config{
+		Issuer:            envHandler.Env(config.ConfJwtIssuer, "whereiseveryone"),
+		Audience:          envHandler.Env(config.ConfJwtAudience, "whereiseveryone"),
+		AccessValidity:    mustParseDuration(log, envHandler, config.ConfJwtAccessValidity, "1h"),
+		RefreshValidity:   mustParseDuration(log, envHandler, config.ConfJwtRefreshValidity, "720h"),
+		ChallengeValidity: mustParseDuration(log, envHandler, config.ConfJwtChallengeValidity, "5m"),
+		Leeway:            mustParseDuration(log, envHandler, config.ConfJwtLeeway, "30s"),
+	}
+	jwtInstance := jwt.NewJWT(utcTimer, jwtKeys, jwtConfig)
+	usersAdapter := users.NewMongoAdapter(
+		mongoCollections.Users,
+		utcTimer,
+		log,
+		jwtConfig.RefreshValidity+jwtConfig.Leeway,
+	)
+	revokedTokensAdapter := tokens.NewMongoAdapter(
+		mongoCollections.RevokedTokens,
+		utcTimer,
+		log,
+		jwtConfig.RefreshValidity+jwtConfig.Leeway,
+	)
 
-	authRouter := authMux.NewMux(usersAdapter, utcTimer, jwtInstance)
-	meRouter := meMux.NewMux(usersAdapter, utcTimer)
+	apiKeysAdapter := apikeys.NewMongoAdapter(mongoCollections.APIKeys, utcTimer, log)
+	groupsAdapter := groups.NewMongoAdapter(mongoCollections.Groups, utcTimer, log)
+	linksAdapter := links.NewMongoAdapter(mongoCollections.ShareLinks, utcTimer, log)
+
+	passwordHasher := newPasswordHasher(envHandler, log)
+	resetsAdapter := resets.NewMongoAdapter(mongoCollections.PasswordResets, utcTimer, log)
+	mailSender := newMailSender(envHandler, log)
+
+	attemptsAdapter := attempts.NewMongoAdapter(mongoCollections.LoginAttempts, utcTimer, log)
+	loginPolicy := attempts.Policy{
+		BaseLockout: mustParseDuration(log, envHandler, config.ConfLoginLockout, "30s"),
+		MaxLockout:  mustParseDuration(log, envHandler, config.ConfLoginMaxLockout, "1h"),
+		Window:      mustParseDuration(log, envHandler, config.ConfLoginAttemptsWindow, "24h"),
+	}
+	accountPolicy, ipPolicy := loginPolicy, loginPolicy
+	accountPolicy.FreeAttempts = mustParseInt(log, envHandler, config.ConfLoginAccountAttempts, "5")
+	ipPolicy.FreeAttempts = mustParseInt(log, envHandler, config.ConfLoginIPAttempts, "20")
+
+	authRouter, err := authMux.NewMux(
+		usersAdapter,
+		revokedTokensAdapter,
+		resetsAdapter,
+		attemptsAdapter,
+		oidcstates.NewMongoAdapter(mongoCollections.OIDCStates, utcTimer, log),
+		mailSender,
+		passwordHasher,
+		utcTimer,
+		jwtInstance,
+		authMux.Config{
+			ResetValidity:     mustParseDuration(log, envHandler, config.ConfPasswordResetValidity, "30m"),
+			AccountPolicy:     accountPolicy,
+			IPPolicy:          ipPolicy,
+			OIDCProviders:     newOIDCProviders(envHandler, utcTimer, log),
+			OIDCStateValidity: mustParseDuration(log, envHandler, config.ConfOIDCStateValidity, "10m"),
+			ReservedUsernames: users.NewReservedUsernames(strings.Split(
+				envHandler.Env(config.ConfReservedUsernames, defaultReservedUsernames), ",")),
+		},
+	)
+	if err != nil {
+		log.Fatalf("init auth router: %s", err.Error())
+	}
+	meRouter := meMux.NewMux(
+		usersAdapter,
+		revokedTokensAdapter,
+		apiKeysAdapter,
+		friendrequests.NewMongoAdapter(mongoCollections.FriendRequests, utcTimer, log),
+		groupsAdapter,
+		shares.NewMongoAdapter(mongoCollections.LocationShares, utcTimer, log),
+		linksAdapter,
+		passwordHasher,
+		utcTimer,
+		jwtInstance,
+		meMux.Config{
+			TOTPIssuer:            envHandler.Env(config.ConfTOTPIssuer, "whereiseveryone"),
+			FriendRequestValidity: mustParseDuration(log, envHandler, config.ConfFriendRequestValidity, "720h"),
+			MaxShareValidity:      mustParseDuration(log, envHandler, config.ConfMaxShareValidity, "168h"),
+			MaxAPIKeys:            mustParseInt(log, envHandler, config.ConfMaxAPIKeys, "20"),
+			MaxLinks:              mustParseInt(log, envHandler, config.ConfMaxShareLinks, "20"),
+		},
+	)
 
 	isDebug := envHandler.MustEnv(config.ConfDebug)
 	validate := validator.New()
@@ -79,11 +183,16 @@ func main() {
 		"/api",
 		validate,
 		jwtInstance,
+		revokedTokensAdapter,
+		apiKeysAdapter,
 		webapi.EchoRouters{
-			Swagger:    echoSwagger.WrapHandler,
-			AuthRouter: authRouter,
-			MeRouter:   meRouter,
+			Swagger:      echoSwagger.WrapHandler,
+			AuthRouter:   authRouter,
+			MeRouter:     meRouter,
+			GroupsRouter: groupsMux.NewMux(groupsAdapter, usersAdapter, utcTimer),
+			PublicRouter: publicMux.NewMux(linksAdapter, usersAdapter, utcTimer, jwtInstance),
 		},
+		newIPExtractor(envHandler, log),
 		log,
 		isDebug == "true")
 
@@ -91,3 +200,141 @@ func main() {
 	port := envHandler.MustEnv(config.ConfAppPort)
 	log.Fatal(e.Start(fmt.Sprintf(":%s", port)))
 }
+
+func mustParseDuration(log logger.Logger, envHandler env.Handler, key env.Key, def string) time.Duration {
+	d, err := time.ParseDuration(envHandler.Env(key, def))
+	if err != nil {
+		log.Fatalf("parse %s: %s", key, err.Error())
+	}
+	return d
+}
+
+// loadJWTKeys loads the key set from app.jwtKeys file, or uses app.jwtSecret (HS256) if the file is not set.
+// When both are set the secret is used to verify tokens issued before switching to the key set.
+func loadJWTKeys(envHandler env.Handler) (*jwt.KeySet, error) {
+	keysPath := envHandler.Env(config.ConfJwtKeys, "")
+	if keysPath == "" {
+		return jwt.NewHMACKeySet([]byte(envHandler.MustEnv(config.ConfJwtSecret))), nil
+	}
+
+	keySet, err := jwt.LoadKeySet(keysPath)
+	if err != nil {
+		return nil, fmt.Errorf("load key set: %w", err)
+	}
+
+	if secret := envHandler.Env(config.ConfJwtSecret, ""); secret != "" {
+		legacyKey := jwt.NewHMACKey("", []byte(secret))
+		legacyKey.SignKey = nil // verify only
+		if err := keySet.Add(legacyKey); err != nil {
+			return nil, fmt.Errorf("add legacy key: %w", err)
+		}
+	}
+
+	return keySet, nil
+}
+
+func mustParseInt(log logger.Logger, envHandler env.Handler, key env.Key, def string) int {
+	i, err := strconv.Atoi(envHandler.Env(key, def))
+	if err != nil {
+		log.Fatalf("parse %s: %s", key, err.Error())
+	}
+	return i
+}
+
+// mustParseUint parses unsigned integer which fits in bitSize bits
+func mustParseUint(log logger.Logger, envHandler env.Handler, key env.Key, def string, bitSize int) uint64 {
+	u, err := strconv.ParseUint(envHandler.Env(key, def), 10, bitSize)
+	if err != nil {
+		log.Fatalf("parse %s: %s", key, err.Error())
+	}
+	return u
+}
+
+func newPasswordHasher(envHandler env.Handler, log logger.Logger) *crypto.Hasher {
+	var hasher *crypto.Hasher
+	switch algorithm := crypto.Algorithm(envHandler.Env(config.ConfPasswordHash, "argon2id")); algorithm {
+	case crypto.AlgorithmBcrypt:
+		hasher = crypto.NewBcryptHasher(mustParseInt(log, envHandler, config.ConfBcryptCost, "12"))
+	case crypto.AlgorithmArgon2id:
+		//nolint:gosec // values are range checked by mustParseUint
+		hasher = crypto.NewArgon2idHasher(crypto.Argon2idParams{
+			Memory:  uint32(mustParseUint(log, envHandler, config.ConfArgon2idMemory, "19456", 32)),
+			Time:    uint32(mustParseUint(log, envHandler, config.ConfArgon2idTime, "2", 32)),
+			Threads: uint8(mustParseUint(log, envHandler, config.ConfArgon2idThreads, "1", 8)),
+		})
+	default:
+		log.Fatalf("unknown password hash algorithm: %s", algorithm)
+		return nil
+	}
+
+	if err := hasher.Validate(); err != nil {
+		log.Fatalf("password hasher: %s", err.Error())
+	}
+
+	return hasher
+}
+
+// newIPExtractor returns an extractor of the client IP, X-Forwarded-For is used only if it's set by app.trustedProxies.
+// Without trusted proxies the IP of the direct peer is used.
+func newIPExtractor(envHandler env.Handler, log logger.Logger) echo.IPExtractor {
+	proxies := envHandler.Env(config.ConfTrustedProxies, "")
+	if proxies == "" {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range strings.Split(proxies, ",") {
+		_, ipRange, err := net.ParseCIDR(strings.TrimSpace(proxy))
+		if err != nil {
+			log.Fatalf("parse %s: %s", config.ConfTrustedProxies, err.Error())
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+func newMailSender(envHandler env.Handler, log logger.Logger) mail.Sender {
+	switch sender := envHandler.Env(config.ConfMailSender, "log"); sender {
+	case "smtp":
+		return mail.NewSMTPSender(
+			envHandler.MustEnv(config.ConfMailSMTPHost),
+			envHandler.Env(config.ConfMailSMTPPort, "587"),
+			envHandler.Env(config.ConfMailSMTPUser, ""),
+			envHandler.Env(config.ConfMailSMTPPassword, ""),
+			envHandler.MustEnv(config.ConfMailFrom),
+		)
+	case "file":
+		return mail.NewFileSender(envHandler.MustEnv(config.ConfMailDir))
+	case "log":
+		return mail.NewLogSender(log)
+	default:
+		log.Fatalf("unknown mail sender: %s", sender)
+		return nil
+	}
+}
+
+// newOIDCProviders loads OIDC providers from app.oidcProviders file, no providers are configured if it's not set.
+func newOIDCProviders(envHandler env.Handler, timer timer.Timer, log logger.Logger) map[string]*oidc.Provider {
+	providers := map[string]*oidc.Provider{}
+	configPath := envHandler.Env(config.ConfOIDCProviders, "")
+	if configPath == "" {
+		return providers
+	}
+
+	configs, err := oidc.LoadConfigs(configPath)
+	if err != nil {
+		log.Fatalf("loading oidc providers: %s", err.Error())
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for name, c := range configs {
+		providers[name] = oidc.NewProvider(client, timer, c)
+	}
+
+	return providers
+}
@@ -20,24 +20,24 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
+        "/auth/2fa": {
             "post": {
-                "description": "logs in as an exiting users using login and passowrd",
+                "description": "exchanges the challenge token returned by login and TOTP code (or one of recovery codes) for tokens.\nEach challenge token and code can be used only once, failed attempts are counted like log in attempts.",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
                     "auth"
                 ],
-                "summary": "log in",
+                "summary": "log in with the second factor",
                 "parameters": [
                     {
-                        "description": "login details",
-                        "name": "userDetails",
+                        "description": "challenge token and code",
+                        "name": "twoFactorDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/auth.logInRequest"
+                            "$ref": "#/definitions/auth.twoFactorLogInRequest"
                         }
                     }
                 ],
@@ -54,14 +54,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     },
-                    "403": {
-                        "description": "forbidden (invalid password)",
+                    "401": {
+                        "description": "invalid challenge token or code",
                         "schema": {
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     },
-                    "404": {
-                        "description": "user not exists",
+                    "429": {
+                        "description": "too many failed attempts (see Retry-After header)",
                         "schema": {
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
@@ -75,24 +75,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/signup": {
+        "/auth/login": {
             "post": {
-                "description": "creates a new user",
+                "description": "logs in as an exiting users using login and passowrd.\nFailed attempts are counted per account and per IP, after too many of them log in is locked\nfor some time (doubled with every next failed attempt).\nIf the user has 2FA enabled, ` + "`" + `202` + "`" + ` with a short-lived challenge token is returned instead of tokens,\nit has to be exchanged for tokens with the second factor (see /auth/2fa).",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
                     "auth"
                 ],
-                "summary": "sign up as a new user",
+                "summary": "log in",
                 "parameters": [
                     {
-                        "description": "sign up details",
+                        "description": "login details",
                         "name": "userDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/auth.signUpRequest"
+                            "$ref": "#/definitions/auth.logInRequest"
                         }
                     }
                 ],
@@ -103,14 +103,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/auth.authResponse"
                         }
                     },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/auth.challengeResponse"
+                        }
+                    },
                     "400": {
                         "description": "invalid request",
                         "schema": {
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     },
-                    "409": {
-                        "description": "conflict (user with such a name exists)",
+                    "401": {
+                        "description": "invalid username or password",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed attempts (see Retry-After header)",
                         "schema": {
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
@@ -124,24 +136,150 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/friends": {
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "revokes the session (device) the token was issued for",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "log out",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "token revoked",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden (invalid token)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "revokes all tokens issued for the user so far (all sessions)",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "log out everywhere",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "token revoked",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden (invalid token)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
             "get": {
-                "description": "returns all details about observed users",
+                "description": "completes the provider authorization. Logs in the user with the linked identity,\na new user is created if the identity is not linked to any user.\nFor link authorizations the identity is linked and 204 is returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "auth"
+                ],
+                "summary": "OIDC callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "authorization code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "get friends details",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/me.friendDetails"
-                            }
+                            "$ref": "#/definitions/auth.authResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/auth.challengeResponse"
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request (invalid or expired state, or another client started it)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "401": {
+                        "description": "provider authorization failed",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown provider",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "identity is already linked",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     },
                     "500": {
@@ -153,30 +291,83 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/observe": {
+        "/auth/oidc/{provider}/link": {
             "post": {
-                "description": "start observing the user, the second user must observe requester too to get his details",
-                "consumes": [
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "returns the provider authorization page URL, after the authorization\nthe provider identity is linked to the logged user (the callback returns 204).\nThe URL must be opened by the browser which sent this request (` + "`" + `oidc_binding` + "`" + ` cookie is set),\nthe callback opened by anyone else is rejected.",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "auth"
                 ],
-                "summary": "observe the user",
+                "summary": "link OIDC identity",
                 "parameters": [
                     {
-                        "description": "user to observe",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/me.observeRequest"
+                            "$ref": "#/definitions/auth.oidcLinkResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden (invalid token)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown provider",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "redirects to the provider authorization page (authorization code flow with PKCE).\nThe provider redirects back to /auth/oidc/{provider}/callback,\nthe callback must be opened by the same browser (` + "`" + `oidc_binding` + "`" + ` cookie is set).",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "log in with OIDC provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "name of the device, used to identify the session",
+                        "name": "device_name",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "302": {
+                        "description": "Found"
                     },
                     "400": {
                         "description": "invalid request",
@@ -185,7 +376,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "requested user not exists",
+                        "description": "unknown provider",
                         "schema": {
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
@@ -197,24 +388,26 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "stop observing the user, if user is not observed, nothing happen",
+            }
+        },
+        "/auth/password-reset/confirm": {
+            "post": {
+                "description": "sets a new password using the reset code, all user sessions are logged out",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "auth"
                 ],
-                "summary": "unobserve the user",
+                "summary": "confirm password reset",
                 "parameters": [
                     {
-                        "description": "user to unobserve",
-                        "name": "user",
+                        "description": "reset confirmation",
+                        "name": "resetDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/me.observeRequest"
+                            "$ref": "#/definitions/auth.passwordResetConfirmRequest"
                         }
                     }
                 ],
@@ -228,8 +421,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     },
-                    "404": {
-                        "description": "requested user not exists",
+                    "403": {
+                        "description": "invalid or expired code",
                         "schema": {
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
@@ -243,27 +436,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/status": {
-            "put": {
-                "description": "updates logged user status (text status)",
+        "/auth/password-reset/request": {
+            "post": {
+                "description": "sends a single-use reset code to the user email.\nThe response is the same whether the user exists or not.\nRequests are counted per account and per IP, after too many of them the reset is locked for some time.",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "me"
+                    "auth"
                 ],
-                "summary": "update status",
+                "summary": "request password reset",
                 "parameters": [
                     {
-                        "description": "update status object",
-                        "name": "status",
+                        "description": "reset details",
+                        "name": "resetDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/me.updateStatusRequest"
+                            "$ref": "#/definitions/auth.passwordResetRequest"
                         }
                     }
                 ],
@@ -277,6 +467,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     },
+                    "429": {
+                        "description": "too many requests (see Retry-After header)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
                     "500": {
                         "description": "internal server error",
                         "schema": {
@@ -286,30 +482,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/updateLocation": {
-            "put": {
-                "description": "update logged user location",
+        "/auth/refresh": {
+            "post": {
+                "description": "issues a new pair of tokens in exchange for a valid refresh token.\nEach refresh token can be used only once, reusing already rotated token revokes the session.",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "auth"
                 ],
-                "summary": "update location",
+                "summary": "refresh tokens",
                 "parameters": [
                     {
-                        "description": "update location object",
-                        "name": "location",
+                        "description": "refresh details",
+                        "name": "refreshDetails",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/me.updateLocationRequest"
+                            "$ref": "#/definitions/auth.refreshRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.authResponse"
+                        }
                     },
                     "400": {
                         "description": "invalid request",
@@ -317,6 +516,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/jsonerr.JSONError"
                         }
                     },
+                    "401": {
+                        "description": "invalid, expired or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
                     "500": {
                         "description": "internal server error",
                         "schema": {
@@ -325,89 +530,3177 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.authResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "description": "ID is user id (uuid)",
-                    "type": "string"
-                },
-                "refresh_token": {
-                    "description": "RefreshToken user refresh token",
-                    "type": "string"
-                },
-                "token": {
-                    "description": "Token user auth token (Bearer)",
-                    "type": "string"
-                }
-            }
         },
-        "auth.logInRequest": {
-            "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
-            "properties": {
-                "password": {
-                    "description": "Password user password",
-                    "type": "string"
+        "/auth/signup": {
+            "post": {
+                "description": "creates a new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "sign up as a new user",
+                "parameters": [
+                    {
+                        "description": "sign up details",
+                        "name": "userDetails",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.signUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.authResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict (user with such a name exists or the name is reserved)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups": {
+            "get": {
+                "description": "returns groups the requester is a member of",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "get groups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/groups.groupSummary"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "creates a new group, the requester becomes its owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "create group",
+                "parameters": [
+                    {
+                        "description": "group to create",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/groups.createGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/groups.groupDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/invitations": {
+            "get": {
+                "description": "returns groups the requester is invited to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "get group invitations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/groups.invitationDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}": {
+            "get": {
+                "description": "returns the group with its members and roles, pending invitations are returned to admins only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "get group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/groups.groupDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group not exists or the requester is not a member",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "removes the group, only the owner can delete it",
+                "tags": [
+                    "groups"
+                ],
+                "summary": "delete group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "the requester is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group not exists or the requester is not a member",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/decline": {
+            "post": {
+                "description": "removes the invitation",
+                "tags": [
+                    "groups"
+                ],
+                "summary": "decline group invitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "the requester is not invited",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/invites": {
+            "post": {
+                "description": "invites the user to the group, only owner and admins can invite",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "invite to group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user to invite",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/groups.inviteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "the requester is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group or user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "the user is already a member or invited",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/join": {
+            "post": {
+                "description": "accepts the invitation, the requester becomes a member and shares the location with other members",
+                "tags": [
+                    "groups"
+                ],
+                "summary": "join group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "the requester is not invited",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/leave": {
+            "post": {
+                "description": "removes the requester from the group, the owner cannot leave",
+                "tags": [
+                    "groups"
+                ],
+                "summary": "leave group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group not exists or the requester is not a member",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "the requester is the owner",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/members": {
+            "get": {
+                "description": "returns details of other group members (membership is a consent to share the location),\nthe location has the precision set by the member for the requester (see /me/precision),\notherwise friends of the member get the exact location and others the member's group precision\n(see /groups/{id}/precision). Ghost mode is respected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "get group members details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/groups.memberDetails"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group not exists or the requester is not a member",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/members/{username}": {
+            "delete": {
+                "description": "removes the member, the owner can remove anyone, admins can remove members only",
+                "tags": [
+                    "groups"
+                ],
+                "summary": "remove group member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "member username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "insufficient role",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group or member not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/members/{username}/role": {
+            "put": {
+                "description": "changes the member role, only the owner can do it.\nSetting owner role transfers the ownership, the previous owner becomes an admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "set group member role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "member username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/groups.setRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "the requester is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group or member not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/precision": {
+            "put": {
+                "description": "sets the precision of the requester location shared with group members who are not requester's friends\n(exact by default), the precision set for a member with /me/precision takes priority",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "set group location precision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "precision",
+                        "name": "precision",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/groups.setGroupPrecisionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "group not exists or the requester is not a member",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "delete": {
+                "description": "removes the logged user permanently, the user is removed from other users' observed lists,\nall user tokens are revoked and API keys removed.\nAccounts created with OIDC have no password, they set it with the password reset first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "delete account",
+                "parameters": [
+                    {
+                        "description": "password confirmation",
+                        "name": "confirmation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.deleteAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden (invalid password or the account has no password)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/2fa": {
+            "post": {
+                "description": "generates a new TOTP secret, 2FA is enabled after the first code is verified (see /me/2fa/verify).\nCalling it again before the verification replaces the secret.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "start 2FA enrollment",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/me.enrollTwoFactorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "disables 2FA, requires the password and TOTP code (or one of recovery codes).\nAccounts created with OIDC have no password, they set it with the password reset first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "disable 2FA",
+                "parameters": [
+                    {
+                        "description": "password and code",
+                        "name": "confirmation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.disableTwoFactorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request (2FA is not enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden (invalid password or code, or the account has no password)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/2fa/verify": {
+            "post": {
+                "description": "verifies the first TOTP code and enables 2FA, returns one-time recovery codes (shown only once)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "enable 2FA",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.verifyTwoFactorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/me.recoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request (invalid code or enrollment not started)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/api-keys": {
+            "get": {
+                "description": "returns API keys of the user (without the keys themselves)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.apiKeyDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "creates a long-lived key for a device which can't log in (e.g. GPS tracker).\nThe key can be used only to update the location (send it in X-Api-Key header), it's shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "create API key",
+                "parameters": [
+                    {
+                        "description": "key details",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.createAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/me.createAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "too many keys",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/api-keys/{id}": {
+            "delete": {
+                "description": "revokes the API key, it cannot be used anymore",
+                "tags": [
+                    "me"
+                ],
+                "summary": "delete API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "key id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "key not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/blocks": {
+            "get": {
+                "description": "returns users blocked by the requester",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get blocked users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.blockedUserDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "blocks the user, users stop observing each other, pending friend requests and location shares\nbetween them are removed.\nThe blocked user cannot observe nor send friend requests to the requester,\nthe requester is not found for them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "block the user",
+                "parameters": [
+                    {
+                        "description": "user to block",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.blockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "unblocks the user, previous relationship is not restored. If user is not blocked, nothing happen",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "unblock the user",
+                "parameters": [
+                    {
+                        "description": "user to unblock",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.blockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/email": {
+            "put": {
+                "description": "updates logged user email, used for password reset",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "update email",
+                "parameters": [
+                    {
+                        "description": "update email object",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.updateEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/export": {
+            "get": {
+                "description": "returns all data stored about the logged user (secrets like password hash and tokens are omitted),\nwith ` + "`" + `format=zip` + "`" + ` the data is returned as a zip archive with a single json file",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "export personal data",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "description": "export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/me.exportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/friend-requests": {
+            "post": {
+                "description": "sends a request to observe each other. If the user has already sent a request to the requester,\nit's accepted instead (204).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "send friend request",
+                "parameters": [
+                    {
+                        "description": "user to send the request to",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.friendRequestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/me.friendRequestDetails"
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "request already sent, users are friends already or the user is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/friend-requests/incoming": {
+            "get": {
+                "description": "returns pending requests sent to the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get incoming friend requests",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.friendRequestDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/friend-requests/outgoing": {
+            "get": {
+                "description": "returns pending requests sent by the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get outgoing friend requests",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.friendRequestDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/friend-requests/{id}": {
+            "delete": {
+                "description": "removes outgoing request",
+                "tags": [
+                    "me"
+                ],
+                "summary": "cancel friend request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "request not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/friend-requests/{id}/accept": {
+            "post": {
+                "description": "accepts incoming request, users start observing each other",
+                "tags": [
+                    "me"
+                ],
+                "summary": "accept friend request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "request not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/friend-requests/{id}/decline": {
+            "post": {
+                "description": "removes incoming request, the sender is not notified",
+                "tags": [
+                    "me"
+                ],
+                "summary": "decline friend request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "request not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/friends": {
+            "get": {
+                "description": "returns all details about friends (users observing each other) and users sharing their location\nwith the requester for a limited time (see /me/shares). The location has the precision set by the user\n(null if the user shares the status only). Users in ghost mode have the location hidden\nor frozen at the time they paused the sharing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get friends details",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.friendDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/ghost": {
+            "get": {
+                "description": "returns the location sharing pause state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get ghost mode",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/me.ghostResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "pauses the location sharing with everyone (friends, groups and shares) without unfriending anyone,\nindefinitely or until the given time. In hidden mode the location is not shown,\nin frozen mode the location from the time of the pause is shown.\nThe location can be still updated, it's shown again when the sharing is resumed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "enable ghost mode",
+                "parameters": [
+                    {
+                        "description": "ghost mode",
+                        "name": "ghost",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.setGhostRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/me.ghostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "resumes the location sharing, if it's not paused nothing happens",
+                "tags": [
+                    "me"
+                ],
+                "summary": "disable ghost mode",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/identities": {
+            "get": {
+                "description": "returns external (OIDC) identities linked to the user, see /auth/oidc/{provider}/link",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get linked identities",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.identityDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/identities/{provider}": {
+            "delete": {
+                "description": "removes the provider identity from the user,\nthe last identity of user without a password cannot be removed",
+                "tags": [
+                    "me"
+                ],
+                "summary": "unlink identity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "identity is not linked",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "the identity is the only way to log in",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/links": {
+            "get": {
+                "description": "returns active share links of the user with their view counts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get share links",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.linkDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "creates a public link to the live location for people without an account (GET /public/share/{token}).\nThe token is shown only once, the link can be opened by at most max_viewers distinct viewers\nand works until it expires or is revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "create share link",
+                "parameters": [
+                    {
+                        "description": "link details",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.createLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/me.createLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request (e.g. expiry in the past or too far)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "too many links",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/links/{id}": {
+            "delete": {
+                "description": "revokes the share link, it can't be opened anymore",
+                "tags": [
+                    "me"
+                ],
+                "summary": "revoke share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "link id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "link not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/observe": {
+            "post": {
+                "description": "sends a friend request to the user (see /me/friend-requests), users observe each other\nafter the request is accepted. Nothing happens if the request is already sent or users are friends.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "observe the user",
+                "parameters": [
+                    {
+                        "description": "user to observe",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.observeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "stop observing the user, if user is not observed, nothing happen",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "unobserve the user",
+                "parameters": [
+                    {
+                        "description": "user to unobserve",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.observeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/password": {
+            "put": {
+                "description": "changes user password, all other sessions are logged out.\nReturns a new pair of tokens for the current session.\nAccounts created with OIDC have no password, they set it with the password reset.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "change password",
+                "parameters": [
+                    {
+                        "description": "current and new password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.changePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/me.tokensResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden (invalid current password or the account has no password)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/precision": {
+            "put": {
+                "description": "sets the precision of the location shared with the user (friend or group member):\nexact, about 1 km, about 10 km or status only. Approximate locations are snapped to a fixed grid.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "set location precision",
+                "parameters": [
+                    {
+                        "description": "precision",
+                        "name": "precision",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.setPrecisionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/relationships": {
+            "get": {
+                "description": "returns users only observed by the requester (following), users only observing the requester (followers)\nand users observing each other with the requester (mutual), each user is in one of the lists.\nOnly mutual users see each other's details.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get relationships",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/me.relationshipsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions": {
+            "get": {
+                "description": "returns all logged-in devices of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.sessionDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions/{id}": {
+            "delete": {
+                "description": "logs out the device, all tokens issued for the session are revoked",
+                "tags": [
+                    "me"
+                ],
+                "summary": "delete session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "session id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "session not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/shares": {
+            "get": {
+                "description": "returns active time-limited location shares of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "get location shares",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/me.shareDetails"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "shares the location with the user until the given time, without becoming friends\n(e.g. a marketplace pickup). The user sees the location in /me/friends, the sharing stops automatically.\nAn existing share with the user is replaced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "share location",
+                "parameters": [
+                    {
+                        "description": "share",
+                        "name": "share",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.createShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/me.shareDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request (e.g. expiry in the past or too far)",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested user not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "409": {
+                        "description": "the user is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/shares/{id}": {
+            "delete": {
+                "description": "stops the time-limited location share before it expires",
+                "tags": [
+                    "me"
+                ],
+                "summary": "stop location share",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "share id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "share not exists",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/status": {
+            "put": {
+                "description": "updates logged user status (text status)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "update status",
+                "parameters": [
+                    {
+                        "description": "update status object",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.updateStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/updateLocation": {
+            "put": {
+                "description": "update logged user location",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "update location",
+                "parameters": [
+                    {
+                        "description": "update location object",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/me.updateLocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/public/share/{token}": {
+            "get": {
+                "description": "returns the live location and status of the user who created the share link, no account is required.\nThe link can be opened by a limited number of distinct viewers identified by ` + "`" + `share_viewer` + "`" + ` cookie.\nRequests without the cookie get it with a redirect to the same URL, they are not counted as viewers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "get shared location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "share link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/public.shareResponse"
+                        }
+                    },
+                    "302": {
+                        "description": "the viewer cookie is set, the request has to be repeated with it"
+                    },
+                    "403": {
+                        "description": "the link reached the maximum number of viewers",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "invalid, expired or revoked link",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/jsonerr.JSONError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.authResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID is user id (uuid)",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "description": "RefreshToken user refresh token",
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "SessionID is an ID of the created (or refreshed) session",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token user auth token (Bearer)",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.challengeResponse": {
+            "type": "object",
+            "properties": {
+                "challenge_token": {
+                    "description": "ChallengeToken is a short-lived token to be exchanged for tokens with the second factor",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.logInRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "device_name": {
+                    "description": "DeviceName optional name of the device, used to identify the session",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "password": {
+                    "description": "Password user password",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Scopes optional scopes of issued tokens (location:write, friends:read, friends:write, profile:read, profile:write),\nall scopes are granted if empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "username": {
+                    "description": "Username",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.oidcLinkResponse": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "description": "URL of the provider authorization page the user should open",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.passwordResetConfirmRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "new_password",
+                "username"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code sent to the user",
+                    "type": "string"
+                },
+                "new_password": {
+                    "description": "NewPassword new user password, min 8 characters",
+                    "type": "string",
+                    "minLength": 8
+                },
+                "username": {
+                    "description": "Username of the user who forgot the password",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.passwordResetRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "description": "Username of the user who forgot the password",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.refreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "description": "RefreshToken refresh token returned by signup, login or previous refresh",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.signUpRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "device_name": {
+                    "description": "DeviceName optional name of the device, used to identify the session",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "email": {
+                    "description": "Email optional email, required for password reset",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password user password, min 8 characters",
+                    "type": "string",
+                    "minLength": 8
+                },
+                "username": {
+                    "description": "Username username, must be unique (case-insensitive), 3-32 letters (of a single script), digits, ` + "`" + `.` + "`" + `, ` + "`" + `_` + "`" + ` or ` + "`" + `-` + "`" + `",
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3
+                }
+            }
+        },
+        "auth.twoFactorLogInRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "description": "ChallengeToken returned by login",
+                    "type": "string"
+                },
+                "code": {
+                    "description": "Code is TOTP code or one of recovery codes",
+                    "type": "string"
+                },
+                "device_name": {
+                    "description": "DeviceName optional name of the device, used to identify the session",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "groups.createGroupRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Name of the group, e.g. \"family\"",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "groups.groupDetails": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invites": {
+                    "description": "Invites are pending invitations, returned to the owner and admins only",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/groups.groupInviteDetails"
+                    }
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/groups.groupMemberDetails"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "groups.groupInviteDetails": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "invited_by": {
+                    "description": "InvitedBy is a username of the member who sent the invitation",
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "groups.groupMemberDetails": {
+            "type": "object",
+            "properties": {
+                "joined_at": {
+                    "description": "JoinedAt in UTC time",
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "groups.groupSummary": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "members_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role of the requester in the group",
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ]
+                }
+            }
+        },
+        "groups.invitationDetails": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is a group id",
+                    "type": "string"
+                },
+                "invited_by": {
+                    "description": "InvitedBy is a username of the member who sent the invitation",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "groups.inviteRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "description": "Username of the user to invite",
+                    "type": "string"
+                }
+            }
+        },
+        "groups.locationDetails": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "type": "number"
+                },
+                "altitude": {
+                    "type": "number"
+                },
+                "bearing": {
+                    "type": "number"
+                },
+                "last_update": {
+                    "description": "LastUpdate in UTC time",
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                }
+            }
+        },
+        "groups.memberDetails": {
+            "type": "object",
+            "properties": {
+                "location": {
+                    "description": "Location is null if the user has no location or shares the status only",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/groups.locationDetails"
+                        }
+                    ]
+                },
+                "location_hidden": {
+                    "description": "Hidden is true if the user hides the location from everyone (ghost mode)",
+                    "type": "boolean"
+                },
+                "precision": {
+                    "description": "Precision of the shared location",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                },
+                "status": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "groups.setGroupPrecisionRequest": {
+            "type": "object",
+            "required": [
+                "precision"
+            ],
+            "properties": {
+                "precision": {
+                    "description": "Precision of the location shared with group members who are not friends",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                }
+            }
+        },
+        "groups.setRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "Role is a new member role, owner transfers the ownership",
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ]
+                }
+            }
+        },
+        "jsonerr.JSONError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is desired http code for this error",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Err is a golang error returned by the app\nIt is removed in production application (TBD)",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Message is human friendly error message",
+                    "type": "string"
+                }
+            }
+        },
+        "me.apiKeyDetails": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "hint": {
+                    "description": "Hint is the beginning of the key",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "LastUsedAt in UTC time (null if never used)",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "me.blockRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "description": "Username of the user to block or unblock",
+                    "type": "string"
+                }
+            }
+        },
+        "me.blockedUserDetails": {
+            "type": "object",
+            "properties": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "me.changePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "description": "CurrentPassword user password",
+                    "type": "string"
+                },
+                "new_password": {
+                    "description": "NewPassword new user password, min 8 characters",
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "me.createAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Name of the key (device), e.g. \"bike tracker\"",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "me.createAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "hint": {
+                    "description": "Hint is the beginning of the key",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Key is the API key, it's shown only once (send it in X-Api-Key header)",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "LastUsedAt in UTC time (null if never used)",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "me.createLinkRequest": {
+            "type": "object",
+            "required": [
+                "expires_at",
+                "max_viewers",
+                "name"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt tells when the link stops working (UTC time)",
+                    "type": "string"
+                },
+                "max_viewers": {
+                    "description": "MaxViewers is a number of distinct viewers who can open the link",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "name": {
+                    "description": "Name of the link, e.g. \"grandma\"",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "precision": {
+                    "description": "Precision of the shared location (exact by default)",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                }
+            }
+        },
+        "me.createLinkResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt in UTC time",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_viewers": {
+                    "description": "MaxViewers is a number of distinct viewers who can open the link",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "precision": {
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                },
+                "token": {
+                    "description": "Token of the link, it's shown only once (the link is /api/public/share/{token})",
+                    "type": "string"
+                },
+                "viewers": {
+                    "description": "Viewers is a number of distinct viewers who opened the link",
+                    "type": "integer"
+                },
+                "views": {
+                    "description": "Views is a number of times the link was opened",
+                    "type": "integer"
+                }
+            }
+        },
+        "me.createShareRequest": {
+            "type": "object",
+            "required": [
+                "expires_at",
+                "username"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt tells when the sharing stops (UTC time)",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Username of the user to share the location with",
+                    "type": "string"
+                }
+            }
+        },
+        "me.deleteAccountRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "description": "Password user password, required to confirm the deletion",
+                    "type": "string"
+                }
+            }
+        },
+        "me.disableTwoFactorRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code is TOTP code or one of recovery codes",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password user password",
+                    "type": "string"
+                }
+            }
+        },
+        "me.enrollTwoFactorResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "description": "Secret is base32 encoded TOTP secret, for manual entry in authenticator app",
+                    "type": "string"
+                },
+                "uri": {
+                    "description": "URI is otpauth provisioning URI, to be shown as QR code",
+                    "type": "string"
+                }
+            }
+        },
+        "me.exportAuth": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.sessionDetails"
+                    }
+                },
+                "two_factor_enabled": {
+                    "description": "TwoFactorEnabled tells if 2FA is enabled (the secret is not exported)",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "me.exportResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "description": "APIKeys are device API keys (without the keys themselves)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.apiKeyDetails"
+                    }
+                },
+                "auth": {
+                    "$ref": "#/definitions/me.exportAuth"
+                },
+                "blocked": {
+                    "description": "Blocked are usernames of users blocked by the user",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exported_at": {
+                    "description": "ExportedAt in UTC time",
+                    "type": "string"
+                },
+                "ghost": {
+                    "description": "Ghost is the location sharing pause state",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/me.ghostResponse"
+                        }
+                    ]
+                },
+                "groups": {
+                    "description": "Groups are names of groups the user is a member of",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "identities": {
+                    "description": "Identities are linked external identities",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.identityDetails"
+                    }
+                },
+                "links": {
+                    "description": "Links are active public share links (without tokens)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.linkDetails"
+                    }
+                },
+                "location": {
+                    "description": "Location is the last user location (null if never updated)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/me.locationDetails"
+                        }
+                    ]
+                },
+                "observed": {
+                    "description": "Observed are usernames of users observed by the user",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "observers": {
+                    "description": "Observers are usernames of users observing the user",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "shares": {
+                    "description": "Shares are active time-limited location shares",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.shareDetails"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "me.friendDetails": {
+            "type": "object",
+            "properties": {
+                "location": {
+                    "description": "Location is null if the user has no location or shares the status only",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/me.locationDetails"
+                        }
+                    ]
+                },
+                "location_hidden": {
+                    "description": "Hidden is true if the user hides the location from everyone (ghost mode)",
+                    "type": "boolean"
+                },
+                "precision": {
+                    "description": "Precision of the shared location",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                },
+                "shared_until": {
+                    "description": "SharedUntil in UTC time, set if the user is not a friend but shares the location for a limited time",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 },
                 "username": {
-                    "description": "Username",
                     "type": "string"
                 }
             }
         },
-        "auth.signUpRequest": {
+        "me.friendRequestDetails": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt in UTC time",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Username of the other user (sender of incoming or receiver of outgoing request)",
+                    "type": "string"
+                }
+            }
+        },
+        "me.friendRequestRequest": {
             "type": "object",
             "required": [
-                "password",
                 "username"
             ],
             "properties": {
-                "password": {
-                    "description": "Password user password, min 8 characters",
+                "username": {
+                    "description": "Username of the user to send the request to",
+                    "type": "string"
+                }
+            }
+        },
+        "me.ghostResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "description": "Enabled is true if the location sharing is paused",
+                    "type": "boolean"
+                },
+                "mode": {
                     "type": "string",
-                    "minLength": 8
+                    "enum": [
+                        "hidden",
+                        "frozen"
+                    ]
                 },
-                "username": {
-                    "description": "Username username, must be unique",
+                "since": {
+                    "description": "Since in UTC time",
+                    "type": "string"
+                },
+                "until": {
+                    "description": "Until in UTC time, null - until disabled",
                     "type": "string"
                 }
             }
         },
-        "jsonerr.JSONError": {
+        "me.identityDetails": {
             "type": "object",
             "properties": {
-                "code": {
-                    "description": "Code is desired http code for this error",
-                    "type": "integer"
+                "email": {
+                    "description": "Email is the user email in the provider (can be empty)",
+                    "type": "string"
                 },
-                "error": {
-                    "description": "Err is a golang error returned by the app\nIt is removed in production application (TBD)",
+                "linked_at": {
+                    "description": "LinkedAt in UTC time",
                     "type": "string"
                 },
-                "message": {
-                    "description": "Message is human friendly error message",
+                "provider": {
+                    "description": "Provider is a name of OIDC provider",
+                    "type": "string"
+                },
+                "subject": {
+                    "description": "Subject is the user ID in the provider",
                     "type": "string"
                 }
             }
         },
-        "me.friendDetails": {
+        "me.linkDetails": {
             "type": "object",
             "properties": {
-                "location": {
-                    "$ref": "#/definitions/me.locationDetails"
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
                 },
-                "status": {
+                "expires_at": {
+                    "description": "ExpiresAt in UTC time",
                     "type": "string"
                 },
-                "username": {
+                "id": {
+                    "type": "string"
+                },
+                "max_viewers": {
+                    "description": "MaxViewers is a number of distinct viewers who can open the link",
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
+                },
+                "precision": {
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                },
+                "viewers": {
+                    "description": "Viewers is a number of distinct viewers who opened the link",
+                    "type": "integer"
+                },
+                "views": {
+                    "description": "Views is a number of times the link was opened",
+                    "type": "integer"
                 }
             }
         },
@@ -443,6 +3736,200 @@ const docTemplate = `{
                 }
             }
         },
+        "me.recoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "description": "RecoveryCodes are one-time codes which can be used instead of TOTP code, shown only once",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "me.relationshipDetails": {
+            "type": "object",
+            "properties": {
+                "precision": {
+                    "description": "Precision of the user location shared with the other user",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                },
+                "since": {
+                    "description": "Since in UTC time, when the relationship was created (null for relationships created before it was tracked)",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "State is mutual, following (only the user observes) or follower (only the other user observes)",
+                    "type": "string",
+                    "enum": [
+                        "mutual",
+                        "following",
+                        "follower"
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "me.relationshipsResponse": {
+            "type": "object",
+            "properties": {
+                "followers": {
+                    "description": "Followers are users observing the user, who aren't observed by the user",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.relationshipDetails"
+                    }
+                },
+                "following": {
+                    "description": "Following are users observed by the user, who don't observe the user",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.relationshipDetails"
+                    }
+                },
+                "mutual": {
+                    "description": "Mutual are users observing each other with the user",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/me.relationshipDetails"
+                    }
+                }
+            }
+        },
+        "me.sessionDetails": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Current is true for the session used for the request",
+                    "type": "boolean"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "LastUsedAt in UTC time, updated when the session tokens are refreshed",
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "me.setGhostRequest": {
+            "type": "object",
+            "required": [
+                "mode"
+            ],
+            "properties": {
+                "mode": {
+                    "description": "Mode is hidden (the location is not shown) or frozen (the current location is shown until the sharing is resumed)",
+                    "type": "string",
+                    "enum": [
+                        "hidden",
+                        "frozen"
+                    ]
+                },
+                "until": {
+                    "description": "Until is a time when the sharing is resumed automatically (UTC time), null - until disabled",
+                    "type": "string"
+                }
+            }
+        },
+        "me.setPrecisionRequest": {
+            "type": "object",
+            "required": [
+                "precision",
+                "username"
+            ],
+            "properties": {
+                "precision": {
+                    "description": "Precision of the location shared with the user",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                },
+                "username": {
+                    "description": "Username of the user to set the precision for",
+                    "type": "string"
+                }
+            }
+        },
+        "me.shareDetails": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt in UTC time",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt in UTC time",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Username of the user the location is shared with",
+                    "type": "string"
+                }
+            }
+        },
+        "me.tokensResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID is user id (uuid)",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "description": "RefreshToken user refresh token",
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "SessionID is an ID of the current session",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token user auth token (Bearer)",
+                    "type": "string"
+                }
+            }
+        },
+        "me.updateEmailRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email used for password reset",
+                    "type": "string"
+                }
+            }
+        },
         "me.updateLocationRequest": {
             "type": "object",
             "properties": {
@@ -474,9 +3961,87 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "me.verifyTwoFactorRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code is TOTP code generated by authenticator app",
+                    "type": "string"
+                }
+            }
+        },
+        "public.locationDetails": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "type": "number"
+                },
+                "altitude": {
+                    "type": "number"
+                },
+                "bearing": {
+                    "type": "number"
+                },
+                "last_update": {
+                    "description": "LastUpdate in UTC time",
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                }
+            }
+        },
+        "public.shareResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt in UTC time, the link doesn't work after it",
+                    "type": "string"
+                },
+                "location": {
+                    "description": "Location is null if the user has no location, shares the status only or hides the location (ghost mode)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/public.locationDetails"
+                        }
+                    ]
+                },
+                "location_hidden": {
+                    "description": "Hidden is true if the user hides the location from everyone (ghost mode)",
+                    "type": "boolean"
+                },
+                "precision": {
+                    "description": "Precision of the shared location",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "1km",
+                        "10km",
+                        "status_only"
+                    ]
+                },
+                "status": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ApiKey": {
+            "type": "apiKey",
+            "name": "X-Api-Key",
+            "in": "header"
+        },
         "Bearer": {
             "type": "apiKey",
             "name": "Authorization",
@@ -0,0 +1,196 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+const (
+	// keyPrefix makes keys recognizable (e.g. by secret scanners)
+	keyPrefix = "wie_"
+	// keyBytes is an entropy of generated keys
+	keyBytes = 32
+	// hintLength is a number of key characters stored in plain text, to let users recognize their keys
+	hintLength = len(keyPrefix) + 6
+)
+
+// APIKey is a long-lived key of a device which can't log in (e.g. GPS tracker)
+type APIKey struct {
+	// ID is internal ID
+	ID id.ID `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// UserID is an ID of the key owner
+	UserID id.ID `bson:"user_id"`
+	// Name is a name given by the user
+	Name string `bson:"name"`
+	// KeyHash is a hash of the key (crypto.HashToken), the key itself is never stored
+	KeyHash string `bson:"key_hash"`
+	// Hint is a beginning of the key
+	Hint string `bson:"hint"`
+	// CreatedAt tells when the key was created
+	CreatedAt time.Time `bson:"created_at"`
+	// LastUsedAt tells when the key was used last time (nil if never)
+	LastUsedAt *time.Time `bson:"last_used_at"`
+}
+
+var ErrKeyNotExists = errors.New("api key not exists")
+
+// NewKey returns a new random key, it's shown to the user only once.
+func NewKey() (string, error) {
+	token, err := crypto.RandomToken(keyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return keyPrefix + token, nil
+}
+
+// Hint returns the stored beginning of the key
+func Hint(key string) string {
+	return key[:min(len(key), hintLength)]
+}
+
+type Adapter interface {
+	// Create stores a new key
+	Create(ctx context.Context, key APIKey) (APIKey, error)
+	// GetByHash returns the key with the hash and updates its last usage, ErrKeyNotExists if there is no such a key
+	GetByHash(ctx context.Context, keyHash string) (APIKey, error)
+	// List returns all keys of the user
+	List(ctx context.Context, userID id.ID) ([]APIKey, error)
+	// Delete removes the user key, returns ErrKeyNotExists if there is no such a key
+	Delete(ctx context.Context, userID, keyID id.ID) error
+	// DeleteUserKeys removes all keys of the user
+	DeleteUserKeys(ctx context.Context, userID id.ID) error
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	hashIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"key_hash": 1,
+		},
+		Options: &options.IndexOptions{
+			Unique: pointers.Pointer(true),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, hashIdx)
+	if err != nil {
+		return fmt.Errorf("create unique key_hash:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created unique index on field `key_hash`")
+
+	userIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"user_id": 1,
+		},
+	}
+
+	_, err = m.coll.Indexes().CreateOne(ctx, userIdx)
+	if err != nil {
+		return fmt.Errorf("create user_id:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created index on field `user_id`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Create(ctx context.Context, key APIKey) (APIKey, error) {
+	key.ID = id.NewID()
+	if _, err := m.coll.InsertOne(ctx, key); err != nil {
+		return APIKey{}, fmt.Errorf("create api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (m *mongoAdapter) GetByHash(ctx context.Context, keyHash string) (APIKey, error) {
+	filter := bson.M{
+		"key_hash": keyHash,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"last_used_at": m.timer.Now(),
+		},
+	}
+
+	var key APIKey
+	if err := m.coll.FindOneAndUpdate(ctx, filter, update).Decode(&key); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return APIKey{}, ErrKeyNotExists
+		}
+		return APIKey{}, fmt.Errorf("get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (m *mongoAdapter) List(ctx context.Context, userID id.ID) ([]APIKey, error) {
+	filter := bson.M{
+		"user_id": userID,
+	}
+
+	c, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("perform find query: %w", err)
+	}
+
+	keys := make([]APIKey, 0)
+	if err := c.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("decode query result: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (m *mongoAdapter) Delete(ctx context.Context, userID, keyID id.ID) error {
+	filter := bson.M{
+		"_id":     keyID,
+		"user_id": userID,
+	}
+
+	res, err := m.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("delete api key: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrKeyNotExists
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) DeleteUserKeys(ctx context.Context, userID id.ID) error {
+	filter := bson.M{
+		"user_id": userID,
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("delete user api keys: %w", err)
+	}
+
+	return nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
@@ -0,0 +1,235 @@
+package attempts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+const (
+	// beginRetries is a number of times the counter is read and replaced again when other attempts changed it
+	beginRetries = 10
+	// contentionLockout is a lockout returned when the counter is changed by too many concurrent attempts
+	contentionLockout = time.Second
+)
+
+var ErrLocked = errors.New("too many failed attempts")
+
+// Attempts is a counter of failed attempts for a key (account or IP)
+type Attempts struct {
+	// Key identifies what is counted, see AccountKey and IPKey
+	Key string `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// Failures is a number of failed attempts in a row
+	Failures int `bson:"failures"`
+	// LockedUntil tells until when next attempts are rejected
+	LockedUntil time.Time `bson:"locked_until"`
+	// ExpiresAt tells when the counter is removed (reset) by TTL index
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Policy tells how failed attempts are punished
+type Policy struct {
+	// FreeAttempts is a number of failed attempts allowed before the first lockout
+	FreeAttempts int
+	// BaseLockout is a lockout after the first attempt over FreeAttempts,
+	// it's doubled with every next failed attempt
+	BaseLockout time.Duration
+	// MaxLockout is a limit of a single lockout
+	MaxLockout time.Duration
+	// Window is a time of inactivity after which failures are forgotten
+	Window time.Duration
+}
+
+// Lockout returns lockout duration after given number of failures
+func (p Policy) Lockout(failures int) time.Duration {
+	over := failures - p.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+	for i := 1; i < over && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, p.MaxLockout)
+}
+
+// Lock returns when the lockout after given number of failures ends (zero time if there is no lockout)
+// and when the counter expires - failures are forgotten after Window since the lockout end.
+func (p Policy) Lock(now time.Time, failures int) (time.Time, time.Time) {
+	lockout := p.Lockout(failures)
+	if lockout == 0 {
+		return time.Time{}, now.Add(p.Window)
+	}
+
+	return now.Add(lockout), now.Add(lockout).Add(p.Window)
+}
+
+// Begin returns the counter with the attempt counted as failed in advance (failures are forgotten after the counter
+// expires). Returns ErrLocked and the current counter if the key is locked.
+// The attempt which would lock the key when it fails locks it right away, so concurrent attempts can't get over
+// the policy while it's verified. The successful attempt is undone with Adapter.Release.
+func (p Policy) Begin(now time.Time, current Attempts) (Attempts, error) {
+	if current.LockedUntil.After(now) {
+		return current, ErrLocked
+	}
+
+	failures := current.Failures
+	if !current.ExpiresAt.After(now) {
+		failures = 0
+	}
+
+	next := Attempts{Key: current.Key, Failures: failures + 1}
+	next.LockedUntil, next.ExpiresAt = p.Lock(now, next.Failures)
+
+	return next, nil
+}
+
+func AccountKey(username string) string {
+	return "account:" + username
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// PasswordResetKey returns a key counting password reset requests of the account or IP key
+func PasswordResetKey(key string) string {
+	return "reset:" + key
+}
+
+type Adapter interface {
+	// Begin counts the attempt as failed in advance, atomically with checking the lockout (see Policy.Begin).
+	// Returns ErrLocked and the counter with the lockout end if the key is locked
+	// or if it's changed by too many concurrent attempts.
+	Begin(ctx context.Context, key string, policy Policy) (Attempts, error)
+	// Release undoes the successful attempt counted by Begin, the lockout set by the attempt is removed
+	Release(ctx context.Context, attempt Attempts) error
+	// Reset removes the counter of the key
+	Reset(ctx context.Context, key string) error
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	ttlIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: pointers.Pointer(int32(0)),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, ttlIdx)
+	if err != nil {
+		return fmt.Errorf("create ttl expires_at:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created TTL index on field `expires_at`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Begin(ctx context.Context, key string, policy Policy) (Attempts, error) {
+	for range beginRetries {
+		// mongo keeps milliseconds, the stored counter must be equal to the one compared below
+		now := m.timer.Now().Truncate(time.Millisecond)
+
+		current := Attempts{Key: key}
+		err := m.coll.FindOne(ctx, bson.M{"_id": key}).Decode(&current)
+		exists := err == nil
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return Attempts{}, fmt.Errorf("find attempts: %w", err)
+		}
+
+		next, err := policy.Begin(now, current)
+		if err != nil {
+			return next, err
+		}
+
+		if !exists {
+			_, err := m.coll.InsertOne(ctx, next)
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			if err != nil {
+				return Attempts{}, fmt.Errorf("count attempt: %w", err)
+			}
+		} else {
+			// replaced only if no other attempt changed the counter since it was read
+			filter := bson.M{
+				"_id":        key,
+				"failures":   current.Failures,
+				"expires_at": current.ExpiresAt,
+			}
+			res, err := m.coll.ReplaceOne(ctx, filter, next)
+			if err != nil {
+				return Attempts{}, fmt.Errorf("count attempt: %w", err)
+			}
+			if res.MatchedCount == 0 {
+				continue
+			}
+		}
+
+		if !next.LockedUntil.IsZero() {
+			m.logger.Warnf("%s locked until %s after %d attempts", key, next.LockedUntil, next.Failures)
+		}
+
+		return next, nil
+	}
+
+	return Attempts{Key: key, LockedUntil: m.timer.Now().Add(contentionLockout)}, ErrLocked
+}
+
+func (m *mongoAdapter) Release(ctx context.Context, attempt Attempts) error {
+	filter := bson.M{
+		"_id":      attempt.Key,
+		"failures": bson.M{"$gt": 0},
+	}
+	if _, err := m.coll.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"failures": -1}}); err != nil {
+		return fmt.Errorf("release attempt: %w", err)
+	}
+	if attempt.LockedUntil.IsZero() {
+		return nil
+	}
+
+	// other attempts were rejected by the lockout, unless it was replaced after it ended
+	filter = bson.M{
+		"_id":          attempt.Key,
+		"locked_until": attempt.LockedUntil,
+	}
+	if _, err := m.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"locked_until": time.Time{}}}); err != nil {
+		return fmt.Errorf("release attempt lockout: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) Reset(ctx context.Context, key string) error {
+	_, err := m.coll.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		return fmt.Errorf("reset attempts: %w", err)
+	}
+
+	return nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
@@ -0,0 +1,140 @@
+package attempts
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts: 3,
+	BaseLockout:  30 * time.Second,
+	MaxLockout:   5 * time.Minute,
+	Window:       time.Hour,
+}
+
+func Test_Lockout(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 3, want: 0},
+		{failures: 4, want: 30 * time.Second},
+		{failures: 5, want: time.Minute},
+		{failures: 6, want: 2 * time.Minute},
+		{failures: 7, want: 4 * time.Minute},
+		{failures: 8, want: 5 * time.Minute},
+		{failures: 100, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := testPolicy.Lockout(tt.failures); got != tt.want {
+			t.Errorf("lockout after %d failures = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func Test_Lock(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		failures        int
+		wantLockedUntil time.Time
+		wantExpiresAt   time.Time
+	}{
+		{
+			name:            "free attempt",
+			failures:        1,
+			wantLockedUntil: time.Time{},
+			wantExpiresAt:   now.Add(time.Hour),
+		},
+		{
+			name:            "first lockout",
+			failures:        4,
+			wantLockedUntil: now.Add(30 * time.Second),
+			wantExpiresAt:   now.Add(30 * time.Second).Add(time.Hour),
+		},
+		{
+			name:            "max lockout",
+			failures:        20,
+			wantLockedUntil: now.Add(5 * time.Minute),
+			wantExpiresAt:   now.Add(5 * time.Minute).Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lockedUntil, expiresAt := testPolicy.Lock(now, tt.failures)
+			if !lockedUntil.Equal(tt.wantLockedUntil) || !expiresAt.Equal(tt.wantExpiresAt) {
+				t.Fatalf("lock = %s, %s, want %s, %s", lockedUntil, expiresAt, tt.wantLockedUntil, tt.wantExpiresAt)
+			}
+		})
+	}
+}
+
+func Test_Begin(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		current Attempts
+		want    Attempts
+		wantErr error
+	}{
+		{
+			name:    "first attempt",
+			current: Attempts{Key: "k"},
+			want:    Attempts{Key: "k", Failures: 1, ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:    "last free attempt",
+			current: Attempts{Key: "k", Failures: 2, ExpiresAt: now.Add(time.Minute)},
+			want:    Attempts{Key: "k", Failures: 3, ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:    "attempt locking on failure locks right away",
+			current: Attempts{Key: "k", Failures: 3, ExpiresAt: now.Add(time.Minute)},
+			want: Attempts{
+				Key:         "k",
+				Failures:    4,
+				LockedUntil: now.Add(30 * time.Second),
+				ExpiresAt:   now.Add(30 * time.Second).Add(time.Hour),
+			},
+		},
+		{
+			name:    "locked",
+			current: Attempts{Key: "k", Failures: 4, LockedUntil: now.Add(time.Second), ExpiresAt: now.Add(time.Hour)},
+			want:    Attempts{Key: "k", Failures: 4, LockedUntil: now.Add(time.Second), ExpiresAt: now.Add(time.Hour)},
+			wantErr: ErrLocked,
+		},
+		{
+			name:    "lockout ended",
+			current: Attempts{Key: "k", Failures: 4, LockedUntil: now, ExpiresAt: now.Add(time.Hour)},
+			want: Attempts{
+				Key:         "k",
+				Failures:    5,
+				LockedUntil: now.Add(time.Minute),
+				ExpiresAt:   now.Add(time.Minute).Add(time.Hour),
+			},
+		},
+		{
+			name:    "expired counter not removed yet",
+			current: Attempts{Key: "k", Failures: 6, LockedUntil: now.Add(-time.Hour), ExpiresAt: now},
+			want:    Attempts{Key: "k", Failures: 1, ExpiresAt: now.Add(time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testPolicy.Begin(now, tt.current)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("begin error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("begin = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
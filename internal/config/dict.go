@@ -12,7 +12,50 @@ const (
 	ConfMongoX509     env.Key = "mongo.x509"     // required for cloud
 
 	//nolint:gosec // not a credential
-	ConfJwtSecret env.Key = "app.jwtSecret" // required
-	ConfDebug     env.Key = "app.debug"     // required
-	ConfAppPort   env.Key = "app.port"      // required
+	ConfJwtSecret            env.Key = "app.jwtSecret"            // required if app.jwtKeys is not set
+	ConfJwtKeys              env.Key = "app.jwtKeys"              // optional, path to json key set (see jwt.LoadKeySet)
+	ConfJwtAccessValidity    env.Key = "app.jwtAccessValidity"    // optional, go duration (default 1h)
+	ConfJwtRefreshValidity   env.Key = "app.jwtRefreshValidity"   // optional, go duration (default 720h)
+	ConfJwtIssuer            env.Key = "app.jwtIssuer"            // optional (default whereiseveryone)
+	ConfJwtAudience          env.Key = "app.jwtAudience"          // optional (default whereiseveryone)
+	ConfJwtLeeway            env.Key = "app.jwtLeeway"            // optional, go duration, allowed clock skew (default 30s)
+	ConfJwtChallengeValidity env.Key = "app.jwtChallengeValidity" // optional, go duration, 2FA challenge (default 5m)
+	ConfDebug                env.Key = "app.debug"                // required
+	ConfAppPort              env.Key = "app.port"                 // required
+
+	ConfTrustedProxies env.Key = "app.trustedProxies" // optional, comma separated CIDRs of proxies setting X-Forwarded-For
+
+	ConfReservedUsernames env.Key = "app.reservedUsernames" // optional, comma separated list
+
+	ConfPasswordHash    env.Key = "app.passwordHash"    // optional, argon2id or bcrypt (default argon2id)
+	ConfBcryptCost      env.Key = "app.bcryptCost"      // optional (default 12)
+	ConfArgon2idMemory  env.Key = "app.argon2idMemory"  // optional, KiB (default 19456)
+	ConfArgon2idTime    env.Key = "app.argon2idTime"    // optional, iterations (default 2)
+	ConfArgon2idThreads env.Key = "app.argon2idThreads" // optional (default 1)
+
+	ConfPasswordResetValidity env.Key = "app.passwordResetValidity" // optional, go duration (default 30m)
+
+	ConfTOTPIssuer env.Key = "app.totpIssuer" // optional, issuer shown in authenticator apps (default whereiseveryone)
+
+	ConfFriendRequestValidity env.Key = "app.friendRequestValidity" // optional, go duration (default 720h)
+	ConfMaxShareValidity      env.Key = "app.maxShareValidity"      // optional, go duration (default 168h)
+	ConfMaxAPIKeys            env.Key = "app.maxAPIKeys"            // optional, API keys per user (default 20)
+	ConfMaxShareLinks         env.Key = "app.maxShareLinks"         // optional, active share links per user (default 20)
+
+	ConfOIDCProviders     env.Key = "app.oidcProviders"     // optional, path to json providers config (see oidc.LoadConfigs)
+	ConfOIDCStateValidity env.Key = "app.oidcStateValidity" // optional, go duration (default 10m)
+
+	ConfLoginAccountAttempts env.Key = "app.loginAccountAttempts" // optional, failed attempts before lockout (default 5)
+	ConfLoginIPAttempts      env.Key = "app.loginIPAttempts"      // optional, failed attempts before lockout (default 20)
+	ConfLoginLockout         env.Key = "app.loginLockout"         // optional, go duration, first lockout (default 30s)
+	ConfLoginMaxLockout      env.Key = "app.loginMaxLockout"      // optional, go duration (default 1h)
+	ConfLoginAttemptsWindow  env.Key = "app.loginAttemptsWindow"  // optional, go duration (default 24h)
+
+	ConfMailSender       env.Key = "mail.sender"       // optional, smtp, file or log (default log)
+	ConfMailFrom         env.Key = "mail.from"         // required for smtp
+	ConfMailSMTPHost     env.Key = "mail.smtpHost"     // required for smtp
+	ConfMailSMTPPort     env.Key = "mail.smtpPort"     // optional for smtp (default 587)
+	ConfMailSMTPUser     env.Key = "mail.smtpUser"     // optional for smtp
+	ConfMailSMTPPassword env.Key = "mail.smtpPassword" // optional for smtp
+	ConfMailDir          env.Key = "mail.dir"          // required for file
 )
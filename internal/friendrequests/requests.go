@@ -0,0 +1,260 @@
+package friendrequests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+// FriendRequest is a pending request to observe each other, there is at most one per users pair and direction
+type FriendRequest struct {
+	// ID is internal ID
+	ID id.ID `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// From is an ID of the user who sent the request
+	From id.ID `bson:"from"`
+	// To is an ID of the user who can accept the request
+	To id.ID `bson:"to"`
+	// CreatedAt tells when the request was sent
+	CreatedAt time.Time `bson:"created_at"`
+	// ExpiresAt tells when the request expires, expired requests are removed by TTL index
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+var (
+	ErrRequestExists    = errors.New("friend request already sent")
+	ErrRequestNotExists = errors.New("friend request not exists")
+)
+
+type Adapter interface {
+	// Create stores a new request, returns ErrRequestExists if there is a pending request already
+	Create(ctx context.Context, request FriendRequest) (FriendRequest, error)
+	// Find returns a pending request between users, ErrRequestNotExists if there is no such a request
+	Find(ctx context.Context, from, to id.ID) (FriendRequest, error)
+	// GetIncoming returns pending request sent to the user, ErrRequestNotExists if there is no such a request
+	GetIncoming(ctx context.Context, requestID, to id.ID) (FriendRequest, error)
+	// Incoming returns pending requests sent to the user
+	Incoming(ctx context.Context, userID id.ID) ([]FriendRequest, error)
+	// Outgoing returns pending requests sent by the user
+	Outgoing(ctx context.Context, userID id.ID) ([]FriendRequest, error)
+	// DeleteIncoming removes pending request sent to the user (accepted or declined),
+	// returns ErrRequestNotExists if there is no such a request
+	DeleteIncoming(ctx context.Context, requestID, to id.ID) (FriendRequest, error)
+	// DeleteOutgoing removes pending request sent by the user (cancelled),
+	// returns ErrRequestNotExists if there is no such a request
+	DeleteOutgoing(ctx context.Context, requestID, from id.ID) error
+	// DeleteBetween removes requests sent between users in both directions
+	DeleteBetween(ctx context.Context, user, otherUser id.ID) error
+	// DeleteUserRequests removes all requests sent by or to the user
+	DeleteUserRequests(ctx context.Context, userID id.ID) error
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	ttlIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: pointers.Pointer(int32(0)),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, ttlIdx)
+	if err != nil {
+		return fmt.Errorf("create ttl expires_at:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created TTL index on field `expires_at`")
+
+	pairIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "from", Value: 1},
+			{Key: "to", Value: 1},
+		},
+		Options: &options.IndexOptions{
+			Unique: pointers.Pointer(true),
+		},
+	}
+
+	_, err = m.coll.Indexes().CreateOne(ctx, pairIdx)
+	if err != nil {
+		return fmt.Errorf("create unique from:1,to:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created unique index on fields `from`, `to`")
+
+	toIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"to": 1,
+		},
+	}
+
+	_, err = m.coll.Indexes().CreateOne(ctx, toIdx)
+	if err != nil {
+		return fmt.Errorf("create to:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created index on field `to`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Create(ctx context.Context, request FriendRequest) (FriendRequest, error) {
+	// expired requests may not be removed by TTL index yet
+	expired := bson.M{
+		"from":       request.From,
+		"to":         request.To,
+		"expires_at": bson.M{"$lte": m.timer.Now()},
+	}
+	if _, err := m.coll.DeleteOne(ctx, expired); err != nil {
+		return FriendRequest{}, fmt.Errorf("delete expired friend request: %w", err)
+	}
+
+	request.ID = id.NewID()
+	if _, err := m.coll.InsertOne(ctx, request); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return FriendRequest{}, ErrRequestExists
+		}
+		return FriendRequest{}, fmt.Errorf("create friend request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (m *mongoAdapter) Find(ctx context.Context, from, to id.ID) (FriendRequest, error) {
+	return m.findOne(ctx, m.pending(bson.M{
+		"from": from,
+		"to":   to,
+	}))
+}
+
+func (m *mongoAdapter) GetIncoming(ctx context.Context, requestID, to id.ID) (FriendRequest, error) {
+	return m.findOne(ctx, m.pending(bson.M{
+		"_id": requestID,
+		"to":  to,
+	}))
+}
+
+func (m *mongoAdapter) findOne(ctx context.Context, filter bson.M) (FriendRequest, error) {
+	var request FriendRequest
+	if err := m.coll.FindOne(ctx, filter).Decode(&request); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return FriendRequest{}, ErrRequestNotExists
+		}
+		return FriendRequest{}, fmt.Errorf("find friend request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (m *mongoAdapter) Incoming(ctx context.Context, userID id.ID) ([]FriendRequest, error) {
+	return m.find(ctx, m.pending(bson.M{"to": userID}))
+}
+
+func (m *mongoAdapter) Outgoing(ctx context.Context, userID id.ID) ([]FriendRequest, error) {
+	return m.find(ctx, m.pending(bson.M{"from": userID}))
+}
+
+func (m *mongoAdapter) DeleteIncoming(ctx context.Context, requestID, to id.ID) (FriendRequest, error) {
+	filter := m.pending(bson.M{
+		"_id": requestID,
+		"to":  to,
+	})
+
+	var request FriendRequest
+	if err := m.coll.FindOneAndDelete(ctx, filter).Decode(&request); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return FriendRequest{}, ErrRequestNotExists
+		}
+		return FriendRequest{}, fmt.Errorf("delete friend request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (m *mongoAdapter) DeleteOutgoing(ctx context.Context, requestID, from id.ID) error {
+	filter := m.pending(bson.M{
+		"_id":  requestID,
+		"from": from,
+	})
+
+	res, err := m.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("delete friend request: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrRequestNotExists
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) DeleteBetween(ctx context.Context, user, otherUser id.ID) error {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"from": user, "to": otherUser},
+			bson.M{"from": otherUser, "to": user},
+		},
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("delete friend requests between users: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) DeleteUserRequests(ctx context.Context, userID id.ID) error {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"from": userID},
+			bson.M{"to": userID},
+		},
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("delete user friend requests: %w", err)
+	}
+
+	return nil
+}
+
+// pending adds not expired condition to the filter
+func (m *mongoAdapter) pending(filter bson.M) bson.M {
+	filter["expires_at"] = bson.M{"$gt": m.timer.Now()}
+	return filter
+}
+
+func (m *mongoAdapter) find(ctx context.Context, filter bson.M) ([]FriendRequest, error) {
+	c, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, fmt.Errorf("perform find query: %w", err)
+	}
+
+	requests := make([]FriendRequest, 0)
+	if err := c.All(ctx, &requests); err != nil {
+		return nil, fmt.Errorf("decode query result: %w", err)
+	}
+
+	return requests, nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
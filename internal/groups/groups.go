@@ -0,0 +1,414 @@
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/timer"
+)
+
+// Role is a role of a group member
+type Role string
+
+const (
+	// RoleOwner can do everything admin can, assign roles and delete the group, there is exactly one owner
+	RoleOwner Role = "owner"
+	// RoleAdmin can invite and remove members
+	RoleAdmin Role = "admin"
+	// RoleMember can see other members and leave the group
+	RoleMember Role = "member"
+)
+
+// rank orders roles, higher rank can manage lower ones
+func (r Role) rank() int {
+	switch r {
+	case RoleOwner:
+		return 2
+	case RoleAdmin:
+		return 1
+	case RoleMember:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// CanManage tells if the role can manage (e.g. remove) members with the other role
+func (r Role) CanManage(other Role) bool {
+	return r.rank() >= RoleAdmin.rank() && r.rank() > other.rank()
+}
+
+// Group is a location-sharing circle, members see each other's details (membership is a consent)
+type Group struct {
+	// ID is internal ID
+	ID id.ID `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// Name is a name given by the owner
+	Name string `bson:"name"`
+	// Members are users who joined the group
+	Members []Member `bson:"members"`
+	// Invites are pending invitations
+	Invites []Invite `bson:"invites"`
+	// CreatedAt tells when the group was created
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type Member struct {
+	UserID id.ID `bson:"user_id"`
+	Role   Role  `bson:"role"`
+	// Precision of the location shared with members who are not friends of the member (exact if not set)
+	Precision users.Precision `bson:"precision,omitempty"`
+	// JoinedAt tells when the user joined the group
+	JoinedAt time.Time `bson:"joined_at"`
+}
+
+// LocationPrecision returns the precision of the member location shared with other members
+func (m Member) LocationPrecision() users.Precision {
+	if m.Precision == "" {
+		return users.PrecisionExact
+	}
+	return m.Precision
+}
+
+type Invite struct {
+	UserID id.ID `bson:"user_id"`
+	// InvitedBy is an ID of the member who sent the invitation
+	InvitedBy id.ID `bson:"invited_by"`
+	// CreatedAt tells when the user was invited
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Member returns the group member, false if the user is not a member
+func (g Group) Member(userID id.ID) (Member, bool) {
+	for _, m := range g.Members {
+		if m.UserID == userID {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// Invite returns the invitation of the user, false if the user is not invited
+func (g Group) Invite(userID id.ID) (Invite, bool) {
+	for _, i := range g.Invites {
+		if i.UserID == userID {
+			return i, true
+		}
+	}
+	return Invite{}, false
+}
+
+var (
+	ErrGroupNotExists  = errors.New("group not exists")
+	ErrAlreadyMember   = errors.New("user is already a group member or invited")
+	ErrNotInvited      = errors.New("user is not invited to the group")
+	ErrMemberNotExists = errors.New("user is not a group member")
+)
+
+type Adapter interface {
+	// Create stores a new group
+	Create(ctx context.Context, group Group) (Group, error)
+	// Get returns the group, ErrGroupNotExists if there is no such a group
+	Get(ctx context.Context, groupID id.ID) (Group, error)
+	// GetUserGroups returns groups the user is a member of
+	GetUserGroups(ctx context.Context, userID id.ID) ([]Group, error)
+	// GetInvitations returns groups the user is invited to
+	GetInvitations(ctx context.Context, userID id.ID) ([]Group, error)
+	// Delete removes the group, returns ErrGroupNotExists if there is no such a group
+	Delete(ctx context.Context, groupID id.ID) error
+
+	// Invite adds the invitation, returns ErrAlreadyMember if the user is already a member or invited
+	Invite(ctx context.Context, groupID id.ID, invite Invite) error
+	// Join makes the invited user a member, returns ErrNotInvited if there is no invitation
+	Join(ctx context.Context, groupID, userID id.ID) error
+	// DeclineInvite removes the invitation, returns ErrNotInvited if there is no invitation
+	DeclineInvite(ctx context.Context, groupID, userID id.ID) error
+	// RemoveMember removes the member (leaving or removed by admin),
+	// returns ErrMemberNotExists if there is no such a member
+	RemoveMember(ctx context.Context, groupID, userID id.ID) error
+	// SetRole changes the member role (admin or member), returns ErrMemberNotExists if there is no such a member
+	SetRole(ctx context.Context, groupID, userID id.ID, role Role) error
+	// SetPrecision changes the precision of the member location shared with other members,
+	// returns ErrMemberNotExists if there is no such a member
+	SetPrecision(ctx context.Context, groupID, userID id.ID, precision users.Precision) error
+	// TransferOwnership makes the member the owner, the previous owner becomes an admin.
+	// Returns ErrMemberNotExists if there is no such a member
+	TransferOwnership(ctx context.Context, groupID, owner, newOwner id.ID) error
+
+	// DeleteUserGroups removes groups owned by the user, the user membership and invitations of other groups
+	DeleteUserGroups(ctx context.Context, userID id.ID) error
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	membersIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"members.user_id": 1,
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, membersIdx)
+	if err != nil {
+		return fmt.Errorf("create members.user_id:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created index on field `members.user_id`")
+
+	invitesIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"invites.user_id": 1,
+		},
+	}
+
+	_, err = m.coll.Indexes().CreateOne(ctx, invitesIdx)
+	if err != nil {
+		return fmt.Errorf("create invites.user_id:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created index on field `invites.user_id`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Create(ctx context.Context, group Group) (Group, error) {
+	group.ID = id.NewID()
+	if group.Invites == nil {
+		group.Invites = make([]Invite, 0)
+	}
+
+	if _, err := m.coll.InsertOne(ctx, group); err != nil {
+		return Group{}, fmt.Errorf("create group: %w", err)
+	}
+
+	return group, nil
+}
+
+func (m *mongoAdapter) Get(ctx context.Context, groupID id.ID) (Group, error) {
+	var group Group
+	if err := m.coll.FindOne(ctx, bson.M{"_id": groupID}).Decode(&group); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Group{}, ErrGroupNotExists
+		}
+		return Group{}, fmt.Errorf("find group: %w", err)
+	}
+
+	return group, nil
+}
+
+func (m *mongoAdapter) GetUserGroups(ctx context.Context, userID id.ID) ([]Group, error) {
+	return m.find(ctx, bson.M{"members.user_id": userID})
+}
+
+func (m *mongoAdapter) GetInvitations(ctx context.Context, userID id.ID) ([]Group, error) {
+	return m.find(ctx, bson.M{"invites.user_id": userID})
+}
+
+func (m *mongoAdapter) find(ctx context.Context, filter bson.M) ([]Group, error) {
+	c, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("perform find query: %w", err)
+	}
+
+	groups := make([]Group, 0)
+	if err := c.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("decode query result: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (m *mongoAdapter) Delete(ctx context.Context, groupID id.ID) error {
+	res, err := m.coll.DeleteOne(ctx, bson.M{"_id": groupID})
+	if err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrGroupNotExists
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) Invite(ctx context.Context, groupID id.ID, invite Invite) error {
+	filter := bson.M{
+		"_id":             groupID,
+		"members.user_id": bson.M{"$ne": invite.UserID},
+		"invites.user_id": bson.M{"$ne": invite.UserID},
+	}
+	update := bson.M{
+		"$push": bson.M{
+			"invites": invite,
+		},
+	}
+
+	return m.updateOne(ctx, filter, update, ErrAlreadyMember)
+}
+
+func (m *mongoAdapter) Join(ctx context.Context, groupID, userID id.ID) error {
+	filter := bson.M{
+		"_id":             groupID,
+		"invites.user_id": userID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"invites": bson.M{"user_id": userID},
+		},
+		"$push": bson.M{
+			"members": Member{
+				UserID:   userID,
+				Role:     RoleMember,
+				JoinedAt: m.timer.Now(),
+			},
+		},
+	}
+
+	return m.updateOne(ctx, filter, update, ErrNotInvited)
+}
+
+func (m *mongoAdapter) DeclineInvite(ctx context.Context, groupID, userID id.ID) error {
+	filter := bson.M{
+		"_id":             groupID,
+		"invites.user_id": userID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"invites": bson.M{"user_id": userID},
+		},
+	}
+
+	return m.updateOne(ctx, filter, update, ErrNotInvited)
+}
+
+func (m *mongoAdapter) RemoveMember(ctx context.Context, groupID, userID id.ID) error {
+	filter := bson.M{
+		"_id":             groupID,
+		"members.user_id": userID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"members": bson.M{"user_id": userID},
+		},
+	}
+
+	return m.updateOne(ctx, filter, update, ErrMemberNotExists)
+}
+
+func (m *mongoAdapter) SetRole(ctx context.Context, groupID, userID id.ID, role Role) error {
+	filter := bson.M{
+		"_id":             groupID,
+		"members.user_id": userID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"members.$.role": role,
+		},
+	}
+
+	return m.updateOne(ctx, filter, update, ErrMemberNotExists)
+}
+
+func (m *mongoAdapter) SetPrecision(ctx context.Context, groupID, userID id.ID, precision users.Precision) error {
+	filter := bson.M{
+		"_id":             groupID,
+		"members.user_id": userID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"members.$.precision": precision,
+		},
+	}
+
+	return m.updateOne(ctx, filter, update, ErrMemberNotExists)
+}
+
+func (m *mongoAdapter) TransferOwnership(ctx context.Context, groupID, owner, newOwner id.ID) error {
+	filter := bson.M{
+		"_id": groupID,
+		"members": bson.M{
+			"$elemMatch": bson.M{"user_id": owner, "role": RoleOwner},
+		},
+		"members.user_id": newOwner,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"members.$[owner].role":    RoleAdmin,
+			"members.$[newOwner].role": RoleOwner,
+		},
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []any{
+			bson.M{"owner.user_id": owner},
+			bson.M{"newOwner.user_id": newOwner},
+		},
+	})
+
+	res, err := m.coll.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("transfer group ownership: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrMemberNotExists
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) DeleteUserGroups(ctx context.Context, userID id.ID) error {
+	owned := bson.M{
+		"members": bson.M{
+			"$elemMatch": bson.M{"user_id": userID, "role": RoleOwner},
+		},
+	}
+	if _, err := m.coll.DeleteMany(ctx, owned); err != nil {
+		return fmt.Errorf("delete user groups: %w", err)
+	}
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"members.user_id": userID},
+			bson.M{"invites.user_id": userID},
+		},
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"members": bson.M{"user_id": userID},
+			"invites": bson.M{"user_id": userID},
+		},
+	}
+	if _, err := m.coll.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("remove user from groups: %w", err)
+	}
+
+	return nil
+}
+
+// updateOne updates the group, notMatched is returned if no group matches the filter
+func (m *mongoAdapter) updateOne(ctx context.Context, filter, update bson.M, notMatched error) error {
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("update group: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return notMatched
+	}
+
+	return nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
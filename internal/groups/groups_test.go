@@ -0,0 +1,27 @@
+package groups
+
+import "testing"
+
+func Test_CanManage(t *testing.T) {
+	tests := []struct {
+		role  Role
+		other Role
+		want  bool
+	}{
+		{role: RoleOwner, other: RoleAdmin, want: true},
+		{role: RoleOwner, other: RoleMember, want: true},
+		{role: RoleOwner, other: RoleOwner, want: false},
+		{role: RoleAdmin, other: RoleMember, want: true},
+		{role: RoleAdmin, other: RoleAdmin, want: false},
+		{role: RoleAdmin, other: RoleOwner, want: false},
+		{role: RoleMember, other: RoleMember, want: false},
+		{role: RoleMember, other: "unknown", want: false},
+		{role: "unknown", other: "unknown", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.CanManage(tt.other); got != tt.want {
+			t.Errorf("%s.CanManage(%s) = %v, want %v", tt.role, tt.other, got, tt.want)
+		}
+	}
+}
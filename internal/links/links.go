@@ -0,0 +1,205 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+// Link is a public location share link for people without an account.
+// The link token is signed (jwt.TokenTypeShareLink), the link is revoked by removing it.
+type Link struct {
+	// ID is internal ID
+	ID id.ID `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// UserID is an ID of the user sharing the location
+	UserID id.ID `bson:"user_id"`
+	// Name is a name given by the user, e.g. "grandma"
+	Name string `bson:"name"`
+	// Precision is a precision of the shared location (users.Precision)
+	Precision string `bson:"precision"`
+	// MaxViewers is a number of distinct viewers who can open the link
+	MaxViewers int `bson:"max_viewers"`
+	// Viewers are hashes of viewers who opened the link (crypto.HashToken of the viewer cookie)
+	Viewers []string `bson:"viewers"`
+	// Views is a number of times the link was opened
+	Views int `bson:"views"`
+	// CreatedAt tells when the link was created
+	CreatedAt time.Time `bson:"created_at"`
+	// ExpiresAt tells when the link expires, expired links are removed by TTL index
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+var (
+	ErrLinkNotExists  = errors.New("share link not exists")
+	ErrTooManyViewers = errors.New("share link reached the maximum number of viewers")
+)
+
+type Adapter interface {
+	// Create stores a new link
+	Create(ctx context.Context, link Link) (Link, error)
+	// View counts the view of the user link by the viewer. Viewers who opened the link before are always admitted,
+	// new viewers are rejected with ErrTooManyViewers when the link reached its maximum number of viewers.
+	// Returns ErrLinkNotExists if the link was revoked, expired or it's not the user link.
+	View(ctx context.Context, userID, linkID id.ID, viewer string) (Link, error)
+	// List returns active links of the user
+	List(ctx context.Context, userID id.ID) ([]Link, error)
+	// Delete revokes the user link, returns ErrLinkNotExists if there is no such a link
+	Delete(ctx context.Context, userID, linkID id.ID) error
+	// DeleteUserLinks removes all links of the user
+	DeleteUserLinks(ctx context.Context, userID id.ID) error
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	ttlIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: pointers.Pointer(int32(0)),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, ttlIdx)
+	if err != nil {
+		return fmt.Errorf("create ttl expires_at:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created TTL index on field `expires_at`")
+
+	userIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"user_id": 1,
+		},
+	}
+
+	_, err = m.coll.Indexes().CreateOne(ctx, userIdx)
+	if err != nil {
+		return fmt.Errorf("create user_id:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created index on field `user_id`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Create(ctx context.Context, link Link) (Link, error) {
+	link.ID = id.NewID()
+	if link.Viewers == nil {
+		link.Viewers = make([]string, 0)
+	}
+
+	if _, err := m.coll.InsertOne(ctx, link); err != nil {
+		return Link{}, fmt.Errorf("create share link: %w", err)
+	}
+
+	return link, nil
+}
+
+func (m *mongoAdapter) View(ctx context.Context, userID, linkID id.ID, viewer string) (Link, error) {
+	now := m.timer.Now()
+	active := bson.M{
+		"_id":        linkID,
+		"user_id":    userID,
+		"expires_at": bson.M{"$gt": now},
+	}
+	// known viewers or new ones below the limit, checked atomically with recording the viewer
+	filter := bson.M{
+		"_id":        linkID,
+		"user_id":    userID,
+		"expires_at": bson.M{"$gt": now},
+		"$or": bson.A{
+			bson.M{"viewers": viewer},
+			bson.M{"$expr": bson.M{"$lt": bson.A{bson.M{"$size": "$viewers"}, "$max_viewers"}}},
+		},
+	}
+	update := bson.M{
+		"$addToSet": bson.M{"viewers": viewer},
+		"$inc":      bson.M{"views": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var link Link
+	err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&link)
+	if err == nil {
+		return link, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return Link{}, fmt.Errorf("view share link: %w", err)
+	}
+
+	// tell apart links which reached the limit from revoked (or expired) ones
+	err = m.coll.FindOne(ctx, active).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Link{}, ErrLinkNotExists
+	}
+	if err != nil {
+		return Link{}, fmt.Errorf("find share link: %w", err)
+	}
+
+	return Link{}, ErrTooManyViewers
+}
+
+func (m *mongoAdapter) List(ctx context.Context, userID id.ID) ([]Link, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"expires_at": bson.M{"$gt": m.timer.Now()},
+	}
+
+	c, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, fmt.Errorf("perform find query: %w", err)
+	}
+
+	links := make([]Link, 0)
+	if err := c.All(ctx, &links); err != nil {
+		return nil, fmt.Errorf("decode query result: %w", err)
+	}
+
+	return links, nil
+}
+
+func (m *mongoAdapter) Delete(ctx context.Context, userID, linkID id.ID) error {
+	filter := bson.M{
+		"_id":     linkID,
+		"user_id": userID,
+	}
+
+	res, err := m.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("delete share link: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrLinkNotExists
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) DeleteUserLinks(ctx context.Context, userID id.ID) error {
+	if _, err := m.coll.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return fmt.Errorf("delete user share links: %w", err)
+	}
+
+	return nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
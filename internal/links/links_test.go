@@ -0,0 +1,94 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/timer"
+)
+
+func Test_View(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	link := Link{
+		ID:         id.NewID(),
+		UserID:     id.NewID(),
+		MaxViewers: 1,
+		Viewers:    []string{"known"},
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	ns := "db.links"
+	found := func() bson.D {
+		return mtest.CreateSuccessResponse(bson.E{Key: "value", Value: link})
+	}
+	notFound := mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil})
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wantErr   error
+	}{
+		{name: "admitted viewer", responses: []bson.D{found()}},
+		{
+			name:      "new viewer of a full link",
+			responses: []bson.D{notFound, mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, mustDoc(mt, link))},
+			wantErr:   ErrTooManyViewers,
+		},
+		{
+			name:      "revoked, expired or another user link",
+			responses: []bson.D{notFound, mtest.CreateCursorResponse(0, ns, mtest.FirstBatch)},
+			wantErr:   ErrLinkNotExists,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+			adapter := NewMongoAdapter(mt.Coll, timer.NewUTCTimer(), nil)
+
+			_, err := adapter.View(context.Background(), link.UserID, link.ID, "viewer")
+			if !errors.Is(err, tt.wantErr) {
+				mt.Fatalf("View() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// the limit is checked by the update filter, atomically with recording the viewer
+			query := mt.GetStartedEvent().Command.Lookup("query").Document()
+			if got := query.Lookup("_id").ObjectID(); got != link.ID {
+				mt.Errorf("query _id = %v, want %v", got, link.ID)
+			}
+			if got := query.Lookup("user_id").ObjectID(); got != link.UserID {
+				mt.Errorf("query user_id = %v, want %v (the link of another user must not be viewed)", got, link.UserID)
+			}
+			if _, ok := query.Lookup("expires_at", "$gt").DateTimeOK(); !ok {
+				mt.Errorf("query doesn't skip expired links: %s", query)
+			}
+			admits := query.Lookup("$or").Array()
+			if got := admits.Index(0).Value().Document().Lookup("viewers").StringValue(); got != "viewer" {
+				mt.Errorf("known viewers are not admitted, $or: %s", admits)
+			}
+			limit := admits.Index(1).Value().Document().Lookup("$expr", "$lt").Array()
+			if size := limit.Index(0).Value().Document().Lookup("$size").StringValue(); size != "$viewers" ||
+				limit.Index(1).Value().StringValue() != "$max_viewers" {
+				mt.Errorf("new viewers are not limited by max_viewers, $or: %s", admits)
+			}
+		})
+	}
+}
+
+func mustDoc(mt *mtest.T, v any) bson.D {
+	mt.Helper()
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		mt.Fatalf("marshal: %v", err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		mt.Fatalf("unmarshal: %v", err)
+	}
+	return doc
+}
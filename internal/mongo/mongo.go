@@ -19,7 +19,16 @@ type Indexable interface {
 type Collections struct {
 	client *mongo.Client
 
-	Users *mongo.Collection
+	Users          *mongo.Collection
+	RevokedTokens  *mongo.Collection
+	PasswordResets *mongo.Collection
+	LoginAttempts  *mongo.Collection
+	OIDCStates     *mongo.Collection
+	APIKeys        *mongo.Collection
+	FriendRequests *mongo.Collection
+	Groups         *mongo.Collection
+	LocationShares *mongo.Collection
+	ShareLinks     *mongo.Collection
 }
 
 func (c *Collections) Disconnect(ctx context.Context) error {
@@ -70,7 +79,16 @@ func newMongo(ctx context.Context, db string, opts *options.ClientOptions) (*Col
 	appDB := cl.Database(db)
 
 	return &Collections{
-		client: cl,
-		Users:  appDB.Collection("users"),
+		client:         cl,
+		Users:          appDB.Collection("users"),
+		RevokedTokens:  appDB.Collection("revoked_tokens"),
+		PasswordResets: appDB.Collection("password_resets"),
+		LoginAttempts:  appDB.Collection("login_attempts"),
+		OIDCStates:     appDB.Collection("oidc_states"),
+		APIKeys:        appDB.Collection("api_keys"),
+		FriendRequests: appDB.Collection("friend_requests"),
+		Groups:         appDB.Collection("groups"),
+		LocationShares: appDB.Collection("location_shares"),
+		ShareLinks:     appDB.Collection("share_links"),
 	}, nil
 }
@@ -0,0 +1,108 @@
+package oidcstates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+// State is a pending OIDC authorization, created when the user is redirected to the provider
+// and consumed by the callback.
+type State struct {
+	// StateHash is a hash of the state parameter (crypto.HashToken)
+	StateHash string `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// Provider is a name of the provider
+	Provider string `bson:"provider"`
+	// Nonce is expected in the ID token
+	Nonce string `bson:"nonce"`
+	// CodeVerifier is PKCE code verifier
+	CodeVerifier string `bson:"code_verifier"`
+	// DeviceName is a name of the device the session is created for
+	DeviceName string `bson:"device_name"`
+	// LinkUserID is set when the identity is linked to the existing user (nil for log in)
+	LinkUserID *id.ID `bson:"link_user_id"`
+	// BindingHash is a hash of the binding cookie (crypto.HashToken) of the client which started the authorization,
+	// the callback is accepted only from the same client
+	BindingHash string `bson:"binding_hash"`
+	// CreatedAt tells when the authorization was started
+	CreatedAt time.Time `bson:"created_at"`
+	// ExpiresAt tells when the state expires, expired states are removed by TTL index
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+var ErrInvalidState = errors.New("invalid or expired state")
+
+type Adapter interface {
+	// Create stores a new state
+	Create(ctx context.Context, state State) error
+	// Consume removes and returns the state of the provider if it's not expired.
+	// Returns ErrInvalidState otherwise.
+	Consume(ctx context.Context, provider, stateHash string) (State, error)
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	ttlIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: pointers.Pointer(int32(0)),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, ttlIdx)
+	if err != nil {
+		return fmt.Errorf("create ttl expires_at:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created TTL index on field `expires_at`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Create(ctx context.Context, state State) error {
+	if _, err := m.coll.InsertOne(ctx, state); err != nil {
+		return fmt.Errorf("create oidc state: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) Consume(ctx context.Context, provider, stateHash string) (State, error) {
+	filter := bson.M{
+		"_id":        stateHash,
+		"provider":   provider,
+		"expires_at": bson.M{"$gt": m.timer.Now()},
+	}
+
+	var state State
+	if err := m.coll.FindOneAndDelete(ctx, filter).Decode(&state); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return State{}, ErrInvalidState
+		}
+		return State{}, fmt.Errorf("consume oidc state: %w", err)
+	}
+
+	return state, nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
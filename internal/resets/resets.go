@@ -0,0 +1,131 @@
+package resets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+// maxAttempts is a number of invalid codes after which the reset is not valid anymore
+const maxAttempts = 5
+
+// Reset is a pending password reset, there is at most one per user
+type Reset struct {
+	// UserID is an ID of user resetting the password
+	UserID id.ID `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// CodeHash is a hash of the code sent to the user (crypto.HashToken)
+	CodeHash string `bson:"code_hash"`
+	// Attempts is a number of invalid codes provided
+	Attempts int `bson:"attempts"`
+	// CreatedAt tells when the reset was requested
+	CreatedAt time.Time `bson:"created_at"`
+	// ExpiresAt tells when the code expires, expired resets are removed by TTL index
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+var ErrInvalidCode = errors.New("invalid or expired reset code")
+
+type Adapter interface {
+	// Create stores a new reset, replacing the previous one of the user.
+	// Invalid attempts of the previous reset are kept if it's not expired yet.
+	Create(ctx context.Context, reset Reset) error
+	// Consume removes the reset if the code hash matches and the reset is not expired.
+	// Returns ErrInvalidCode otherwise.
+	Consume(ctx context.Context, userID id.ID, codeHash string) error
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	ttlIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: pointers.Pointer(int32(0)),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, ttlIdx)
+	if err != nil {
+		return fmt.Errorf("create ttl expires_at:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created TTL index on field `expires_at`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Create(ctx context.Context, reset Reset) error {
+	filter := bson.M{
+		"_id": reset.UserID,
+	}
+	// requesting a new code must not give more attempts to guess it, expressions see the previous reset
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"code_hash":  reset.CodeHash,
+			"created_at": reset.CreatedAt,
+			"expires_at": reset.ExpiresAt,
+			"attempts": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$expires_at", m.timer.Now()}},
+				bson.M{"$max": bson.A{"$attempts", reset.Attempts}},
+				reset.Attempts,
+			}},
+		}}},
+	}
+
+	_, err := m.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("create password reset: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) Consume(ctx context.Context, userID id.ID, codeHash string) error {
+	filter := bson.M{
+		"_id":        userID,
+		"code_hash":  codeHash,
+		"attempts":   bson.M{"$lt": maxAttempts},
+		"expires_at": bson.M{"$gt": m.timer.Now()},
+	}
+
+	res, err := m.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("consume password reset: %w", err)
+	}
+	if res.DeletedCount == 1 {
+		return nil
+	}
+
+	// count the invalid attempt, the reset is useless after maxAttempts
+	update := bson.M{
+		"$inc": bson.M{
+			"attempts": 1,
+		},
+	}
+	if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": userID}, update); err != nil {
+		return fmt.Errorf("count password reset attempt: %w", err)
+	}
+
+	return ErrInvalidCode
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
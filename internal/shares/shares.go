@@ -0,0 +1,206 @@
+package shares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+// Share is a time-limited location sharing with a user who is not a friend (e.g. a marketplace pickup),
+// there is at most one share per users pair and direction
+type Share struct {
+	// ID is internal ID
+	ID id.ID `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// From is an ID of the user who shares the location
+	From id.ID `bson:"from"`
+	// To is an ID of the user who can see the location
+	To id.ID `bson:"to"`
+	// CreatedAt tells when the location was shared
+	CreatedAt time.Time `bson:"created_at"`
+	// ExpiresAt tells when the sharing stops, expired shares are removed by TTL index
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+var ErrShareNotExists = errors.New("share not exists")
+
+type Adapter interface {
+	// Create shares the location, an existing share between the users is replaced
+	Create(ctx context.Context, share Share) (Share, error)
+	// SharedWith returns active shares with the user
+	SharedWith(ctx context.Context, userID id.ID) ([]Share, error)
+	// SharedBy returns active shares of the user
+	SharedBy(ctx context.Context, userID id.ID) ([]Share, error)
+	// Delete stops the share, returns ErrShareNotExists if there is no such a share
+	Delete(ctx context.Context, shareID, from id.ID) error
+	// DeleteBetween removes shares between users in both directions
+	DeleteBetween(ctx context.Context, user, otherUser id.ID) error
+	// DeleteUserShares removes all shares of and with the user
+	DeleteUserShares(ctx context.Context, userID id.ID) error
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+}
+
+func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	ttlIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: pointers.Pointer(int32(0)),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, ttlIdx)
+	if err != nil {
+		return fmt.Errorf("create ttl expires_at:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created TTL index on field `expires_at`")
+
+	pairIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "from", Value: 1},
+			{Key: "to", Value: 1},
+		},
+		Options: &options.IndexOptions{
+			Unique: pointers.Pointer(true),
+		},
+	}
+
+	_, err = m.coll.Indexes().CreateOne(ctx, pairIdx)
+	if err != nil {
+		return fmt.Errorf("create unique from:1,to:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created unique index on fields `from`, `to`")
+
+	toIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"to": 1,
+		},
+	}
+
+	_, err = m.coll.Indexes().CreateOne(ctx, toIdx)
+	if err != nil {
+		return fmt.Errorf("create to:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created index on field `to`")
+
+	return nil
+}
+
+func (m *mongoAdapter) Create(ctx context.Context, share Share) (Share, error) {
+	filter := bson.M{
+		"from": share.From,
+		"to":   share.To,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"created_at": share.CreatedAt,
+			"expires_at": share.ExpiresAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id": id.NewID(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result Share
+	if err := m.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		return Share{}, fmt.Errorf("create share: %w", err)
+	}
+
+	return result, nil
+}
+
+func (m *mongoAdapter) SharedWith(ctx context.Context, userID id.ID) ([]Share, error) {
+	return m.find(ctx, bson.M{"to": userID})
+}
+
+func (m *mongoAdapter) SharedBy(ctx context.Context, userID id.ID) ([]Share, error) {
+	return m.find(ctx, bson.M{"from": userID})
+}
+
+// find returns not expired shares (expired ones may not be removed by TTL index yet)
+func (m *mongoAdapter) find(ctx context.Context, filter bson.M) ([]Share, error) {
+	filter["expires_at"] = bson.M{"$gt": m.timer.Now()}
+
+	c, err := m.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"expires_at": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("perform find query: %w", err)
+	}
+
+	shares := make([]Share, 0)
+	if err := c.All(ctx, &shares); err != nil {
+		return nil, fmt.Errorf("decode query result: %w", err)
+	}
+
+	return shares, nil
+}
+
+func (m *mongoAdapter) Delete(ctx context.Context, shareID, from id.ID) error {
+	filter := bson.M{
+		"_id":  shareID,
+		"from": from,
+	}
+
+	res, err := m.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("delete share: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrShareNotExists
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) DeleteBetween(ctx context.Context, user, otherUser id.ID) error {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"from": user, "to": otherUser},
+			bson.M{"from": otherUser, "to": user},
+		},
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("delete shares between users: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) DeleteUserShares(ctx context.Context, userID id.ID) error {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"from": userID},
+			bson.M{"to": userID},
+		},
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("delete user shares: %w", err)
+	}
+
+	return nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
@@ -0,0 +1,176 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
+	"whereiseveryone/pkg/logger"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/timer"
+)
+
+// Revoked is a denylist entry.
+// It revokes a single token (by jti), all tokens of the session
+// or all user tokens issued before RevokedBefore.
+type Revoked struct {
+	// ID is a key of the entry, see tokenKey, sessionKey and userKey
+	ID string `bson:"_id"` //nolint:tagliatelle // mongo-id
+	// RevokedBefore all tokens issued before are revoked, used by user-wide entries only.
+	// It has millisecond precision (as mongo dates), tokens issued later in the same second stay valid.
+	RevokedBefore *time.Time `bson:"revoked_before,omitempty"`
+	// ExpiresAt tells when the entry can be removed (all revoked tokens are expired by then)
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Revokes tells if the entry revokes the token, the entry has to be one of the token keys.
+// Token and session entries revoke all matching tokens, user-wide entries only tokens issued before RevokedBefore.
+func (r Revoked) Revokes(token jwt.SignedToken) bool {
+	if r.RevokedBefore == nil {
+		return true
+	}
+
+	return r.RevokedBefore.After(token.IssuedTime())
+}
+
+type Adapter interface {
+	// RevokeToken revokes a single token until it expires
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// RevokeSession revokes all tokens issued for the session
+	RevokeSession(ctx context.Context, sessionID id.ID) error
+	// RevokeUserTokens revokes all user tokens issued so far
+	RevokeUserTokens(ctx context.Context, userID id.ID) error
+	// IsRevoked checks if the token is revoked by any of the entries
+	IsRevoked(ctx context.Context, token jwt.SignedToken) (bool, error)
+}
+
+type mongoAdapter struct {
+	coll   *mongo.Collection
+	timer  timer.Timer
+	logger logger.Logger
+
+	// maxValidity is a validity of the longest living token,
+	// session and user-wide entries are kept for that long
+	maxValidity time.Duration
+}
+
+func NewMongoAdapter(
+	coll *mongo.Collection,
+	timer timer.Timer,
+	logger logger.Logger,
+	maxValidity time.Duration,
+) *mongoAdapter {
+	return &mongoAdapter{coll, timer, logger, maxValidity}
+}
+
+func tokenKey(tokenID string) string {
+	return "jti:" + tokenID
+}
+
+func sessionKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+func userKey(userID string) string {
+	return "user:" + userID
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	ttlIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: &options.IndexOptions{
+			ExpireAfterSeconds: pointers.Pointer(int32(0)),
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, ttlIdx)
+	if err != nil {
+		return fmt.Errorf("create ttl expires_at:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created TTL index on field `expires_at`")
+
+	return nil
+}
+
+func (m *mongoAdapter) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return errors.New("revoke token: missing token id")
+	}
+
+	return m.upsert(ctx, tokenKey(tokenID), bson.M{
+		"expires_at": expiresAt,
+	})
+}
+
+func (m *mongoAdapter) RevokeSession(ctx context.Context, sessionID id.ID) error {
+	return m.upsert(ctx, sessionKey(sessionID.Hex()), bson.M{
+		"expires_at": m.timer.Now().Add(m.maxValidity),
+	})
+}
+
+func (m *mongoAdapter) RevokeUserTokens(ctx context.Context, userID id.ID) error {
+	now := m.timer.Now().Truncate(time.Millisecond)
+	return m.upsert(ctx, userKey(userID.Hex()), bson.M{
+		"revoked_before": now,
+		"expires_at":     now.Add(m.maxValidity),
+	})
+}
+
+func (m *mongoAdapter) upsert(ctx context.Context, key string, fields bson.M) error {
+	filter := bson.M{
+		"_id": key,
+	}
+	update := bson.M{
+		"$max": fields,
+	}
+
+	_, err := m.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("revoke %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) IsRevoked(ctx context.Context, token jwt.SignedToken) (bool, error) {
+	keys := bson.A{userKey(token.Subject)}
+	if token.Id != "" {
+		keys = append(keys, tokenKey(token.Id))
+	}
+	if token.SessionID != "" {
+		keys = append(keys, sessionKey(token.SessionID))
+	}
+
+	filter := bson.M{
+		"_id": bson.M{"$in": keys},
+	}
+
+	cursor, err := m.coll.Find(ctx, filter)
+	if err != nil {
+		return false, fmt.Errorf("check token revocation: %w", err)
+	}
+
+	var entries []Revoked
+	if err := cursor.All(ctx, &entries); err != nil {
+		return false, fmt.Errorf("decode revocation entries: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Revokes(token) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
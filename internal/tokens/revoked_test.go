@@ -0,0 +1,69 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
+)
+
+type fakeTimer struct {
+	now time.Time
+}
+
+func (f *fakeTimer) Now() time.Time {
+	return f.now
+}
+
+func Test_Revokes(t *testing.T) {
+	tm := &fakeTimer{now: time.Date(2024, 5, 1, 12, 0, 0, 100*int(time.Millisecond), time.UTC)}
+	j := jwt.NewJWT(tm, jwt.NewHMACKeySet([]byte("secret")), jwt.Config{
+		AccessValidity:  time.Hour,
+		RefreshValidity: time.Hour,
+	})
+	userID := id.NewID()
+
+	issue := func(at time.Time) jwt.SignedToken {
+		t.Helper()
+		tm.now = at
+		token, _, err := j.GenerateTokens("user", userID, id.NewID(), nil)
+		if err != nil {
+			t.Fatalf("generate tokens: %v", err)
+		}
+		claims, err := j.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("validate token: %v", err)
+		}
+		return claims
+	}
+
+	revokedAt := time.Date(2024, 5, 1, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+	userEntry := Revoked{ID: userKey(userID.Hex()), RevokedBefore: &revokedAt}
+
+	legacy := issue(revokedAt.Add(300 * time.Millisecond))
+	legacy.IssuedAtMs = 0
+
+	tests := []struct {
+		name  string
+		entry Revoked
+		token jwt.SignedToken
+		want  bool
+	}{
+		{"issued in earlier second", userEntry, issue(revokedAt.Add(-time.Second)), true},
+		{"issued earlier in the same second", userEntry, issue(revokedAt.Add(-100 * time.Millisecond)), true},
+		{"issued later in the same second", userEntry, issue(revokedAt.Add(100 * time.Millisecond)), false},
+		{"issued in later second", userEntry, issue(revokedAt.Add(time.Second)), false},
+		{"legacy token without iat_ms in the same second", userEntry, legacy, true},
+		{"token entry", Revoked{ID: tokenKey("jti")}, issue(revokedAt.Add(time.Hour)), true},
+		{"session entry", Revoked{ID: sessionKey("sid")}, issue(revokedAt.Add(time.Hour)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.Revokes(tt.token); got != tt.want {
+				t.Fatalf("revokes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package users
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,43 +17,151 @@ type Auth struct {
 	Username string `bson:"username"`
 	// Password is an encrypted password
 	Password string `bson:"password"`
+	// Email is used for account recovery (can be empty)
+	Email string `bson:"email"`
+	// Sessions are logged-in devices, each of them has its own tokens
+	Sessions []Session `bson:"sessions"`
+	// TwoFactor is TOTP second factor configuration (nil if never enrolled)
+	TwoFactor *TwoFactor `bson:"two_factor,omitempty"`
+	// CreatedAt tells when the user was created
+	CreatedAt time.Time `bson:"created_at"`
+	// UpdatedAt tells when the last update was done
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// Session is a single logged-in device
+type Session struct {
+	// ID is session ID, stored in tokens issued for the session
+	ID id.ID `bson:"id"`
+	// DeviceName is a name of the device given by the client (can be empty)
+	DeviceName string `bson:"device_name"`
+	// UserAgent is user agent of the client which created the session
+	UserAgent string `bson:"user_agent"`
+	// IP is an address of the client which created the session
+	IP string `bson:"ip"`
 	// Token is a jwt-token
 	Token string `bson:"token"`
 	// RefreshToken is jwt-refresh-token
 	RefreshToken string `bson:"refresh_token"`
-	// CreatedAt tells when the user was created
+	// CreatedAt tells when the session was created (log in)
 	CreatedAt time.Time `bson:"created_at"`
-	// UpdatedAt tells when the last update was done
-	UpdatedAt time.Time `bson:"updated_at"`
+	// LastUsedAt tells when the session tokens were refreshed last time
+	LastUsedAt time.Time `bson:"last_used_at"`
 }
 
+// TwoFactor is TOTP second factor of the user
+type TwoFactor struct {
+	// Secret is base32 encoded TOTP secret
+	Secret string `bson:"secret"`
+	// Enabled is false until the enrollment is verified with the first code
+	Enabled bool `bson:"enabled"`
+	// LastStep is the last accepted TOTP time step, codes for it and earlier steps are rejected (replay protection)
+	LastStep int64 `bson:"last_step"`
+	// RecoveryCodes are hashes of unused one-time recovery codes
+	RecoveryCodes []string `bson:"recovery_codes"`
+	// EnabledAt tells when 2FA was enabled
+	EnabledAt *time.Time `bson:"enabled_at"`
+}
+
+// IsEnabled tells if the second factor is required to log in
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.Enabled
+}
+
+var (
+	ErrRefreshTokenReused  = errors.New("refresh token is not valid anymore")
+	ErrSessionNotExists    = errors.New("session not exists")
+	ErrCodeAlreadyUsed     = errors.New("code has been already used")
+	ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+)
+
 type authAdapter interface {
-	// UpdateTokens update user tokens (if they are not nil)
-	UpdateTokens(ctx context.Context, userID id.ID, token, refreshedToken *string) error
+	// NewSession adds a new session to the user, expired sessions are removed
+	NewSession(ctx context.Context, userID id.ID, session Session) error
+	// UpdateTokens update session tokens (if they are not nil)
+	UpdateTokens(ctx context.Context, userID, sessionID id.ID, token, refreshedToken *string) error
+	// RotateTokens replaces session tokens only if the current refresh token is equal to oldRefreshToken,
+	// expired sessions are removed. Returns ErrRefreshTokenReused otherwise.
+	RotateTokens(ctx context.Context, userID, sessionID id.ID, oldRefreshToken, token, refreshedToken string) error
+	// DeleteSession removes the session, returns ErrSessionNotExists if there is no such a session
+	DeleteSession(ctx context.Context, userID, sessionID id.ID) error
+	// DeleteSessions removes all user sessions except the ones listed in keep
+	DeleteSessions(ctx context.Context, userID id.ID, keep ...id.ID) error
+	// UpdatePassword replaces user password with the new (encrypted) one
+	UpdatePassword(ctx context.Context, userID id.ID, encryptedPassword string) error
+	// UpdateEmail replaces user email
+	UpdateEmail(ctx context.Context, userID id.ID, email string) error
+	// SetTwoFactor replaces user second factor configuration, nil removes it
+	SetTwoFactor(ctx context.Context, userID id.ID, twoFactor *TwoFactor) error
+	// UseTOTPStep marks TOTP time step as used, returns ErrCodeAlreadyUsed if the step (or a later one) was used
+	UseTOTPStep(ctx context.Context, userID id.ID, step int64) error
+	// UseRecoveryCode removes the recovery code (hash), returns ErrInvalidRecoveryCode if there is no such a code
+	UseRecoveryCode(ctx context.Context, userID id.ID, codeHash string) error
 }
 
 type mongoAuthAdapter struct {
 	coll   *mongo.Collection
 	timer  timer.Timer
 	logger logger.Logger
+
+	// sessionValidity is a validity of the refresh token,
+	// sessions not used (refreshed) for that long have all tokens expired
+	sessionValidity time.Duration
 }
 
-func (m mongoAuthAdapter) UpdateTokens(ctx context.Context, userID id.ID, token, refreshedToken *string) error {
+func withSession(userID, sessionID id.ID) bson.M {
+	return bson.M{
+		"_id":              userID,
+		"auth.sessions.id": sessionID,
+	}
+}
+
+func (m mongoAuthAdapter) NewSession(ctx context.Context, userID id.ID, session Session) error {
+	if err := m.pruneSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	filter := withUserId(userID)
+	update := bson.M{
+		"$push": bson.M{
+			"auth.sessions": session,
+		},
+		"$set": bson.M{
+			"auth.updated_at": m.timer.Now(),
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotExists
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) UpdateTokens(
+	ctx context.Context,
+	userID, sessionID id.ID,
+	token, refreshedToken *string,
+) error {
 	tokens := bson.D{}
 	if token != nil {
-		tokens = append(tokens, bson.E{Key: "auth.token", Value: *token})
+		tokens = append(tokens, bson.E{Key: "auth.sessions.$.token", Value: *token})
 	}
 	if refreshedToken != nil {
-		tokens = append(tokens, bson.E{Key: "auth.refresh_token", Value: *refreshedToken})
+		tokens = append(tokens, bson.E{Key: "auth.sessions.$.refresh_token", Value: *refreshedToken})
 	}
-	tokens = append(tokens, bson.E{Key: "auth.updated_at", Value: m.timer.Now()})
 
 	if len(tokens) == 0 {
 		// nothing to update
 		return nil
 	}
+	tokens = append(tokens, bson.E{Key: "auth.sessions.$.last_used_at", Value: m.timer.Now()})
 
-	filter := withUserId(userID)
+	filter := withSession(userID, sessionID)
 	update := bson.M{
 		"$set": tokens,
 	}
@@ -65,4 +174,216 @@ func (m mongoAuthAdapter) UpdateTokens(ctx context.Context, userID id.ID, token,
 	return nil
 }
 
+func (m mongoAuthAdapter) RotateTokens(
+	ctx context.Context,
+	userID, sessionID id.ID,
+	oldRefreshToken, token, refreshedToken string,
+) error {
+	filter := bson.M{
+		"_id": userID,
+		"auth.sessions": bson.M{
+			"$elemMatch": bson.M{
+				"id":            sessionID,
+				"refresh_token": oldRefreshToken,
+			},
+		},
+	}
+	update := bson.M{
+		"$set": bson.D{
+			{Key: "auth.sessions.$.token", Value: token},
+			{Key: "auth.sessions.$.refresh_token", Value: refreshedToken},
+			{Key: "auth.sessions.$.last_used_at", Value: m.timer.Now()},
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("rotate tokens: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	return m.pruneSessions(ctx, userID)
+}
+
+// pruneSessions removes sessions with expired refresh tokens, the user document would grow without bound otherwise
+func (m mongoAuthAdapter) pruneSessions(ctx context.Context, userID id.ID) error {
+	if m.sessionValidity <= 0 {
+		return nil
+	}
+
+	filter := withUserId(userID)
+	update := bson.M{
+		"$pull": bson.M{
+			"auth.sessions": bson.M{
+				"last_used_at": bson.M{"$lt": m.timer.Now().Add(-m.sessionValidity)},
+			},
+		},
+	}
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("prune sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) DeleteSession(ctx context.Context, userID, sessionID id.ID) error {
+	filter := withSession(userID, sessionID)
+	update := bson.M{
+		"$pull": bson.M{
+			"auth.sessions": bson.M{"id": sessionID},
+		},
+		"$set": bson.M{
+			"auth.updated_at": m.timer.Now(),
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrSessionNotExists
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) DeleteSessions(ctx context.Context, userID id.ID, keep ...id.ID) error {
+	if keep == nil {
+		keep = make([]id.ID, 0)
+	}
+
+	filter := withUserId(userID)
+	update := bson.M{
+		"$pull": bson.M{
+			"auth.sessions": bson.M{
+				"id": bson.M{"$nin": keep},
+			},
+		},
+		"$set": bson.M{
+			"auth.updated_at": m.timer.Now(),
+		},
+	}
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("delete sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) UpdatePassword(ctx context.Context, userID id.ID, encryptedPassword string) error {
+	filter := withUserId(userID)
+	update := bson.M{
+		"$set": bson.M{
+			"auth.password":   encryptedPassword,
+			"auth.updated_at": m.timer.Now(),
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotExists
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) UpdateEmail(ctx context.Context, userID id.ID, email string) error {
+	filter := withUserId(userID)
+	update := bson.M{
+		"$set": bson.M{
+			"auth.email":      email,
+			"auth.updated_at": m.timer.Now(),
+		},
+	}
+
+	_, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("update email: %w", err)
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) SetTwoFactor(ctx context.Context, userID id.ID, twoFactor *TwoFactor) error {
+	filter := withUserId(userID)
+	set := bson.M{
+		"auth.updated_at": m.timer.Now(),
+	}
+	update := bson.M{
+		"$set": set,
+	}
+	if twoFactor == nil {
+		update["$unset"] = bson.M{"auth.two_factor": ""}
+	} else {
+		set["auth.two_factor"] = twoFactor
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("set two factor: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotExists
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) UseTOTPStep(ctx context.Context, userID id.ID, step int64) error {
+	filter := bson.M{
+		"_id":                       userID,
+		"auth.two_factor.last_step": bson.M{"$lt": step},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"auth.two_factor.last_step": step,
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("use totp step: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrCodeAlreadyUsed
+	}
+
+	return nil
+}
+
+func (m mongoAuthAdapter) UseRecoveryCode(ctx context.Context, userID id.ID, codeHash string) error {
+	filter := bson.M{
+		"_id":                            userID,
+		"auth.two_factor.recovery_codes": codeHash,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"auth.two_factor.recovery_codes": codeHash,
+		},
+		"$set": bson.M{
+			"auth.updated_at": m.timer.Now(),
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("use recovery code: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrInvalidRecoveryCode
+	}
+
+	return nil
+}
+
 var _ authAdapter = (*mongoAuthAdapter)(nil)
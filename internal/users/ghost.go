@@ -0,0 +1,84 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"whereiseveryone/pkg/id"
+)
+
+// GhostMode tells what other users see while the sharing is paused
+type GhostMode string
+
+const (
+	// GhostHidden hides the location
+	GhostHidden GhostMode = "hidden"
+	// GhostFrozen shows the location from the time the sharing was paused
+	GhostFrozen GhostMode = "frozen"
+)
+
+// Ghost is a paused location sharing, it applies to all friends, groups and shares
+type Ghost struct {
+	Mode GhostMode `bson:"mode"`
+	// Since tells when the sharing was paused
+	Since time.Time `bson:"since"`
+	// Until tells when the sharing is resumed automatically (nil - until disabled)
+	Until *time.Time `bson:"until,omitempty"`
+	// FrozenLocation is the location at the time the sharing was paused (frozen mode only)
+	FrozenLocation *Location `bson:"frozen_location,omitempty"`
+}
+
+// IsActive tells if the sharing is paused at the time
+func (g *Ghost) IsActive(now time.Time) bool {
+	return g != nil && (g.Until == nil || now.Before(*g.Until))
+}
+
+// LocationHidden tells if the user hides the location from everyone (ghost mode)
+func (u User) LocationHidden(now time.Time) bool {
+	return u.Ghost.IsActive(now) && u.Ghost.Mode == GhostHidden
+}
+
+// sharedLocation returns the location shown to other users, taking ghost mode into account
+func (u User) sharedLocation(now time.Time) *Location {
+	if !u.Ghost.IsActive(now) {
+		return u.Location
+	}
+
+	switch u.Ghost.Mode {
+	case GhostFrozen:
+		return u.Ghost.FrozenLocation
+	case GhostHidden:
+	}
+	return nil
+}
+
+type ghostAdapter interface {
+	// SetGhost pauses the location sharing, nil resumes it
+	SetGhost(ctx context.Context, userID id.ID, ghost *Ghost) error
+}
+
+func (m *mongoUserAdapter) SetGhost(ctx context.Context, userID id.ID, ghost *Ghost) error {
+	update := bson.M{
+		"$set": bson.M{
+			"ghost": ghost,
+		},
+	}
+	if ghost == nil {
+		update = bson.M{
+			"$unset": bson.M{
+				"ghost": "",
+			},
+		}
+	}
+
+	_, err := m.coll.UpdateOne(ctx, withUserId(userID), update)
+	if err != nil {
+		return fmt.Errorf("set ghost mode: %w", err)
+	}
+
+	return nil
+}
+
+var _ ghostAdapter = (*mongoUserAdapter)(nil)
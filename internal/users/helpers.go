@@ -10,3 +10,11 @@ func withUserId(id id.ID) bson.M {
 		"_id": id,
 	}
 }
+
+func subscribedSinceKey(id id.ID) string {
+	return "subscribed_since." + id.Hex()
+}
+
+func locationPrecisionKey(id id.ID) string {
+	return "location_precision." + id.Hex()
+}
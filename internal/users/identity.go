@@ -0,0 +1,144 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/pointers"
+)
+
+const identityIndexName = "identities.provider_subject"
+
+// Identity is an external (OIDC) identity linked to the user
+type Identity struct {
+	// Provider is a name of the provider (as configured)
+	Provider string `bson:"provider"`
+	// Subject is the user ID in the provider (`sub` claim)
+	Subject string `bson:"subject"`
+	// Email is the user email in the provider (can be empty)
+	Email string `bson:"email"`
+	// LinkedAt tells when the identity was linked
+	LinkedAt time.Time `bson:"linked_at"`
+}
+
+var (
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to a user")
+	ErrIdentityNotLinked     = errors.New("identity is not linked")
+)
+
+type identityAdapter interface {
+	// GetUserByIdentity returns user with linked identity, ErrUserNotExists if there is no such a user
+	GetUserByIdentity(ctx context.Context, provider, subject string) (User, error)
+	// LinkIdentity links the identity to the user, returns ErrIdentityAlreadyLinked
+	// if the identity or another identity of the provider is linked already
+	LinkIdentity(ctx context.Context, userID id.ID, identity Identity) error
+	// UnlinkIdentity removes the provider identity, returns ErrIdentityNotLinked if there is no such an identity
+	UnlinkIdentity(ctx context.Context, userID id.ID, provider string) error
+}
+
+type mongoIdentityAdapter struct {
+	coll *mongo.Collection
+}
+
+func (m mongoIdentityAdapter) ensureIndexes(ctx context.Context) error {
+	// users without identities are not indexed
+	unique := options.IndexOptions{
+		Name:   pointers.Pointer(identityIndexName),
+		Unique: pointers.Pointer(true),
+		PartialFilterExpression: bson.M{
+			"identities.subject": bson.M{"$exists": true},
+		},
+	}
+	identityIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "identities.provider", Value: 1},
+			{Key: "identities.subject", Value: 1},
+		},
+		Options: &unique,
+	}
+
+	if _, err := m.coll.Indexes().CreateOne(ctx, identityIdx); err != nil {
+		return fmt.Errorf("create unique identities index: %w", err)
+	}
+
+	return nil
+}
+
+func (m mongoIdentityAdapter) GetUserByIdentity(ctx context.Context, provider, subject string) (User, error) {
+	filter := bson.M{
+		"identities": bson.M{
+			"$elemMatch": bson.M{
+				"provider": provider,
+				"subject":  subject,
+			},
+		},
+	}
+
+	var user User
+	if err := m.coll.FindOne(ctx, filter).Decode(&user); err != nil {
+		return User{}, fmt.Errorf("perform query: %w", err)
+	}
+
+	return user, nil
+}
+
+func (m mongoIdentityAdapter) LinkIdentity(ctx context.Context, userID id.ID, identity Identity) error {
+	filter := bson.M{
+		"_id":                 userID,
+		"identities.provider": bson.M{"$ne": identity.Provider},
+	}
+	update := bson.M{
+		"$push": bson.M{
+			"identities": identity,
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		if isIdentityDuplicate(err) {
+			return ErrIdentityAlreadyLinked
+		}
+		return fmt.Errorf("link identity: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrIdentityAlreadyLinked
+	}
+
+	return nil
+}
+
+func (m mongoIdentityAdapter) UnlinkIdentity(ctx context.Context, userID id.ID, provider string) error {
+	filter := bson.M{
+		"_id":                 userID,
+		"identities.provider": provider,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"identities": bson.M{"provider": provider},
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("unlink identity: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrIdentityNotLinked
+	}
+
+	return nil
+}
+
+// isIdentityDuplicate tells if the error is a duplicate key error of identities index
+func isIdentityDuplicate(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), identityIndexName)
+}
+
+var _ identityAdapter = (*mongoIdentityAdapter)(nil)
@@ -0,0 +1,109 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"whereiseveryone/pkg/geo"
+	"whereiseveryone/pkg/id"
+)
+
+// Precision is a precision of the location shared with other user
+type Precision string
+
+const (
+	// PrecisionExact shares the location as reported by the user
+	PrecisionExact Precision = "exact"
+	// Precision1km shares the center of about 1 km grid cell containing the location
+	Precision1km Precision = "1km"
+	// Precision10km shares the center of about 10 km grid cell containing the location
+	Precision10km Precision = "10km"
+	// PrecisionStatusOnly doesn't share the location at all
+	PrecisionStatusOnly Precision = "status_only"
+)
+
+// cellMeters returns the grid cell size of approximate precision, 0 for others
+func (p Precision) cellMeters() float64 {
+	switch p {
+	case Precision1km:
+		return 1_000
+	case Precision10km:
+		return 10_000
+	case PrecisionExact, PrecisionStatusOnly:
+	}
+	return 0
+}
+
+// PrecisionFor returns the precision of the location shared with the viewer (exact by default)
+func (u User) PrecisionFor(viewer id.ID) Precision {
+	return u.PrecisionOr(viewer, PrecisionExact)
+}
+
+// PrecisionOr returns the precision set for the viewer, the fallback if it's not set
+func (u User) PrecisionOr(viewer id.ID, fallback Precision) Precision {
+	if p, ok := u.LocationPrecision[viewer.Hex()]; ok {
+		return p
+	}
+	return fallback
+}
+
+// LocationFor returns the user location with the precision set for the viewer, see LocationWithPrecision
+func (u User) LocationFor(viewer id.ID, now time.Time) *Location {
+	return u.LocationWithPrecision(u.PrecisionFor(viewer), now)
+}
+
+// LocationWithPrecision returns the user location with the precision, nil if it's not shared
+// (or the user has no location). Approximate locations are snapped to a fixed grid, so the exact location
+// can't be recovered from repeated fetches. Altitude and bearing are not shared then.
+// Ghost mode (hidden or frozen location) is applied at the time.
+func (u User) LocationWithPrecision(precision Precision, now time.Time) *Location {
+	location := u.sharedLocation(now)
+	if location == nil || precision == PrecisionStatusOnly {
+		return nil
+	}
+
+	cell := precision.cellMeters()
+	if cell == 0 {
+		return location
+	}
+
+	latitude, longitude := geo.Snap(location.Latitude, location.Longitude, cell)
+	return &Location{
+		Longitude:  longitude,
+		Latitude:   latitude,
+		Accuracy:   max(location.Accuracy, cell/2),
+		LastUpdate: location.LastUpdate,
+	}
+}
+
+type precisionAdapter interface {
+	// SetLocationPrecision sets the precision of the location shared with the viewer
+	SetLocationPrecision(ctx context.Context, userID, viewer id.ID, precision Precision) error
+}
+
+func (m *mongoUserAdapter) SetLocationPrecision(ctx context.Context, userID, viewer id.ID, precision Precision) error {
+	update := bson.M{
+		"$set": bson.M{
+			locationPrecisionKey(viewer): precision,
+		},
+	}
+	if precision == PrecisionExact {
+		// exact is the default
+		update = bson.M{
+			"$unset": bson.M{
+				locationPrecisionKey(viewer): "",
+			},
+		}
+	}
+
+	_, err := m.coll.UpdateOne(ctx, withUserId(userID), update)
+	if err != nil {
+		return fmt.Errorf("set location precision: %w", err)
+	}
+
+	return nil
+}
+
+var _ precisionAdapter = (*mongoUserAdapter)(nil)
@@ -0,0 +1,83 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/totp"
+)
+
+const (
+	recoveryCodesCount  = 10
+	recoveryCodesLength = 10
+)
+
+var (
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrInvalidSecondFactor = errors.New("invalid two-factor code")
+)
+
+// NewRecoveryCodes returns one-time recovery codes to be shown to the user and their hashes to be stored.
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for range recoveryCodesCount {
+		code, err := crypto.RandomCode(recoveryCodesLength)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// VerifySecondFactor checks TOTP code or recovery code of the user with enabled 2FA.
+// Accepted codes are consumed, so none of them can be used twice.
+// Returns ErrInvalidSecondFactor if the code is invalid or already used.
+func VerifySecondFactor(ctx context.Context, adapter Adapter, otp totp.TOTP, now time.Time, u User, code string) error {
+	if !u.Auth.TwoFactor.IsEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	var err error
+	if isTOTPCode(otp, code) {
+		var step int64
+		if step, err = otp.Validate(u.Auth.TwoFactor.Secret, code, now); err == nil {
+			err = adapter.UseTOTPStep(ctx, u.ID, step)
+		}
+	} else {
+		err = adapter.UseRecoveryCode(ctx, u.ID, hashRecoveryCode(code))
+	}
+
+	if errors.Is(err, totp.ErrInvalidCode) || errors.Is(err, ErrCodeAlreadyUsed) ||
+		errors.Is(err, ErrInvalidRecoveryCode) {
+		return fmt.Errorf("%w: %w", ErrInvalidSecondFactor, err)
+	}
+
+	return err
+}
+
+func isTOTPCode(otp totp.TOTP, code string) bool {
+	if len(code) != otp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hashRecoveryCode hashes the code ignoring case and separators users may type
+func hashRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return crypto.HashToken(code)
+}
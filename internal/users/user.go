@@ -8,6 +8,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"slices"
+	"strings"
+	"time"
 	"whereiseveryone/pkg/id"
 	"whereiseveryone/pkg/logger"
 	"whereiseveryone/pkg/pointers"
@@ -30,25 +32,70 @@ type User struct {
 	//		 For now, before returning those user data,
 	//		 we need to make sure both users subscribes each other.
 	SubscribedUsers []id.ID `bson:"subscribed_users"`
+	// SubscribedSince tells when the user started observing the users (by hex ID),
+	// it's missing for subscriptions created before it was introduced
+	SubscribedSince map[string]time.Time `bson:"subscribed_since,omitempty"`
+
+	// BlockedUsers list of IDs of users who cannot observe the user nor send friend requests to them
+	BlockedUsers []id.ID `bson:"blocked_users,omitempty"`
+
+	// LocationPrecision is a precision of the location shared with other users (by hex ID), exact if not set
+	LocationPrecision map[string]Precision `bson:"location_precision,omitempty"`
+	// Ghost is a paused location sharing (nil if the location is shared)
+	Ghost *Ghost `bson:"ghost,omitempty"`
+
+	// Identities are linked external (OIDC) identities
+	Identities []Identity `bson:"identities,omitempty"`
 }
 
 func (u User) SubscribeUser(id id.ID) bool {
 	return slices.Contains(u.SubscribedUsers, id)
 }
 
+// ObservesSince returns when the user started observing the other user, nil if unknown
+func (u User) ObservesSince(id id.ID) *time.Time {
+	since, ok := u.SubscribedSince[id.Hex()]
+	if !ok {
+		return nil
+	}
+	return &since
+}
+
+func (u User) Blocks(id id.ID) bool {
+	return slices.Contains(u.BlockedUsers, id)
+}
+
 type Adapter interface {
 	locationAdapter
 	authAdapter
+	identityAdapter
+	precisionAdapter
+	ghostAdapter
 
 	NewUser(ctx context.Context, user User) (User, error)
 
 	GetUser(ctx context.Context, userID id.ID) (User, error)
 	GetUsers(ctx context.Context, ids []id.ID) ([]User, error)
+	// GetUserByUsername returns the user by username (case-insensitive),
+	// usernames not passing the normalization are looked up exactly
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	// GetObservers returns users who observe the user (reverse lookup on subscribed users)
+	GetObservers(ctx context.Context, userID id.ID) ([]User, error)
 
 	UpdateStatus(ctx context.Context, user id.ID, newStatus string) error
 	ObserveUser(ctx context.Context, user id.ID, userToObserve id.ID) error
 	UnobserveUser(ctx context.Context, user id.ID, userToUnobserve id.ID) error
+	// ObserveEachOther makes users observe each other in a single transaction, cleanups are run within the transaction
+	ObserveEachOther(ctx context.Context, user, otherUser id.ID, cleanups ...func(ctx context.Context) error) error
+
+	// BlockUser adds the user to blocked users and makes both users stop observing each other
+	// in a single transaction, cleanups are run within the transaction
+	BlockUser(ctx context.Context, user, userToBlock id.ID, cleanups ...func(ctx context.Context) error) error
+	UnblockUser(ctx context.Context, user, userToUnblock id.ID) error
+
+	// DeleteUser removes the user and its ID from other users in a single transaction.
+	// Cleanups are run within the transaction too (ctx passed to them is bound to the transaction).
+	DeleteUser(ctx context.Context, user id.ID, cleanups ...func(ctx context.Context) error) error
 }
 
 var ErrUserNotExists = mongo.ErrNoDocuments
@@ -57,21 +104,90 @@ var ErrUserNameAlreadyExists = errors.New("username is already in use")
 type mongoUserAdapter struct {
 	locationAdapter
 	authAdapter
+	identityAdapter
 
 	coll   *mongo.Collection
+	timer  timer.Timer
 	logger logger.Logger
 }
 
-func NewMongoAdapter(coll *mongo.Collection, timer timer.Timer, logger logger.Logger) *mongoUserAdapter {
+// NewMongoAdapter returns users adapter, sessionValidity is a validity of the longest living session token,
+// sessions not used for that long are removed.
+func NewMongoAdapter(
+	coll *mongo.Collection,
+	timer timer.Timer,
+	logger logger.Logger,
+	sessionValidity time.Duration,
+) *mongoUserAdapter {
 	locationAdapter := mongoLocationAdapter{coll, logger}
-	authAdapter := mongoAuthAdapter{coll, timer, logger}
+	authAdapter := mongoAuthAdapter{coll, timer, logger, sessionValidity}
+	identityAdapter := mongoIdentityAdapter{coll}
+
+	return &mongoUserAdapter{locationAdapter, authAdapter, identityAdapter, coll, timer, logger}
+}
 
-	return &mongoUserAdapter{locationAdapter, authAdapter, coll, logger}
+// usernameCollation makes username comparison case-insensitive
+var usernameCollation = &options.Collation{ //nolint:gochecknoglobals // cannot be const
+	Locale:   "en",
+	Strength: 2,
 }
 
 func (m *mongoUserAdapter) EnsureIndexes(ctx context.Context) error {
+	if err := m.ensureUsernameIndex(ctx); err != nil {
+		return err
+	}
+
+	subscribedIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"subscribed_users": 1,
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateOne(ctx, subscribedIdx)
+	if err != nil {
+		return fmt.Errorf("create subscribed_users:1 index: %w", err)
+	}
+
+	m.logger.Infof("Created index on field `subscribed_users`")
+
+	if err := (mongoIdentityAdapter{m.coll}).ensureIndexes(ctx); err != nil {
+		return err
+	}
+
+	m.logger.Infof("Created unique index on fields `identities.provider`, `identities.subject`")
+
+	return nil
+}
+
+// ensureUsernameIndex replaces the case-sensitive username index by the case-insensitive one.
+// The case-insensitive index cannot be created if there are usernames differing only in case,
+// they are reported and the old index is kept until the users are renamed (see README).
+func (m *mongoUserAdapter) ensureUsernameIndex(ctx context.Context) error {
+	duplicates, err := m.duplicatedUsernames(ctx)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		for _, names := range duplicates {
+			m.logger.Warnf("Usernames differing only in case: %s", strings.Join(names, ", "))
+		}
+		m.logger.Warnf("Kept case-sensitive index on field `auth.username`, %d usernames are not unique "+
+			"case-insensitively, rename the users and create the indexes again", len(duplicates))
+
+		return nil
+	}
+
+	// the old, case-sensitive index must be removed, otherwise "Alice" and "alice" are not unique
+	_, err = m.coll.Indexes().DropOne(ctx, "auth.username_1")
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound")) {
+		return fmt.Errorf("drop case-sensitive name:1 index: %w", err)
+	}
+
 	unique := options.IndexOptions{
-		Unique: pointers.Pointer(true),
+		Name:      pointers.Pointer("auth.username_ci"),
+		Unique:    pointers.Pointer(true),
+		Collation: usernameCollation,
 	}
 	userIDIdx := mongo.IndexModel{
 		Keys: bson.M{
@@ -80,20 +196,26 @@ func (m *mongoUserAdapter) EnsureIndexes(ctx context.Context) error {
 		Options: &unique,
 	}
 
-	_, err := m.coll.Indexes().CreateOne(ctx, userIDIdx)
+	_, err = m.coll.Indexes().CreateOne(ctx, userIDIdx)
 	if err != nil {
-		return fmt.Errorf("create unique name:1 index: %w", err)
+		return fmt.Errorf("create unique case-insensitive name:1 index (check for duplicated usernames): %w", err)
 	}
 
-	m.logger.Infof("Created index on field `auth.username`")
+	m.logger.Infof("Created case-insensitive index on field `auth.username`")
 
 	return nil
 }
 
 func (m *mongoUserAdapter) NewUser(ctx context.Context, user User) (User, error) {
 	user.ID = id.NewID()
+	if user.Auth.Sessions == nil {
+		user.Auth.Sessions = []Session{}
+	}
 	_, err := m.coll.InsertOne(ctx, user)
 	if err != nil {
+		if isIdentityDuplicate(err) {
+			return User{}, ErrIdentityAlreadyLinked
+		}
 		var writeErr mongo.WriteException
 		if errors.As(err, &writeErr) {
 			for _, innerErr := range writeErr.WriteErrors {
@@ -147,12 +269,63 @@ func (m *mongoUserAdapter) GetUsers(ctx context.Context, ids []id.ID) ([]User, e
 	return users, nil
 }
 
+// duplicatedUsernames returns groups of usernames equal case-insensitively
+func (m *mongoUserAdapter) duplicatedUsernames(ctx context.Context) ([][]string, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$auth.username",
+			"usernames": bson.M{"$push": "$auth.username"},
+		}}},
+		{{Key: "$match", Value: bson.M{
+			"usernames.1": bson.M{"$exists": true},
+		}}},
+	}
+
+	c, err := m.coll.Aggregate(ctx, pipeline, options.Aggregate().SetCollation(usernameCollation))
+	if err != nil {
+		return nil, fmt.Errorf("find duplicated usernames: %w", err)
+	}
+
+	var groups []struct {
+		Usernames []string `bson:"usernames"`
+	}
+	if err := c.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("decode duplicated usernames: %w", err)
+	}
+
+	result := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, g.Usernames)
+	}
+
+	return result, nil
+}
+
 func (m *mongoUserAdapter) GetUserByUsername(ctx context.Context, name string) (User, error) {
+	normalized, err := NormalizeUsername(name)
+	if err != nil {
+		// usernames registered before the normalization may not pass it, they are looked up exactly
+		return m.findUserByUsername(ctx, name, options.FindOne())
+	}
+
+	user, err := m.findUserByUsername(ctx, normalized, options.FindOne().SetCollation(usernameCollation))
+	if errors.Is(err, ErrUserNotExists) && normalized != name {
+		return m.findUserByUsername(ctx, name, options.FindOne())
+	}
+
+	return user, err
+}
+
+func (m *mongoUserAdapter) findUserByUsername(
+	ctx context.Context,
+	name string,
+	opts *options.FindOneOptions,
+) (User, error) {
 	filter := bson.M{
 		"auth.username": name,
 	}
 
-	res := m.coll.FindOne(ctx, filter)
+	res := m.coll.FindOne(ctx, filter, opts)
 	if err := res.Err(); err != nil {
 		return User{}, fmt.Errorf("perform query: %w", err)
 	}
@@ -165,6 +338,23 @@ func (m *mongoUserAdapter) GetUserByUsername(ctx context.Context, name string) (
 	return user, nil
 }
 
+func (m *mongoUserAdapter) GetObservers(ctx context.Context, userID id.ID) ([]User, error) {
+	filter := bson.M{
+		"subscribed_users": userID,
+	}
+	c, err := m.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("perform find query: %w", err)
+	}
+
+	var users []User
+	if err := c.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("decode query result: %w", err)
+	}
+
+	return users, nil
+}
+
 func (m *mongoUserAdapter) UpdateStatus(ctx context.Context, userId id.ID, newStatus string) error {
 	filter := withUserId(userId)
 	update := bson.M{
@@ -182,11 +372,18 @@ func (m *mongoUserAdapter) UpdateStatus(ctx context.Context, userId id.ID, newSt
 }
 
 func (m *mongoUserAdapter) ObserveUser(ctx context.Context, user id.ID, userToObserve id.ID) error {
-	filter := withUserId(user)
+	// already observed users are skipped to keep the subscription time
+	filter := bson.M{
+		"_id":              user,
+		"subscribed_users": bson.M{"$ne": userToObserve},
+	}
 	update := bson.M{
 		"$addToSet": bson.M{
 			"subscribed_users": userToObserve,
 		},
+		"$set": bson.M{
+			subscribedSinceKey(userToObserve): m.timer.Now(),
+		},
 	}
 
 	_, err := m.coll.UpdateOne(ctx, filter, update)
@@ -203,6 +400,9 @@ func (m *mongoUserAdapter) UnobserveUser(ctx context.Context, user id.ID, userTo
 		"$pull": bson.M{
 			"subscribed_users": userToUnobserve,
 		},
+		"$unset": bson.M{
+			subscribedSinceKey(userToUnobserve): "",
+		},
 	}
 
 	_, err := m.coll.UpdateOne(ctx, filter, update)
@@ -213,4 +413,144 @@ func (m *mongoUserAdapter) UnobserveUser(ctx context.Context, user id.ID, userTo
 	return nil
 }
 
+func (m *mongoUserAdapter) ObserveEachOther(
+	ctx context.Context,
+	user, otherUser id.ID,
+	cleanups ...func(ctx context.Context) error,
+) error {
+	err := m.withTransaction(ctx, func(txCtx context.Context) error {
+		if err := m.ObserveUser(txCtx, user, otherUser); err != nil {
+			return err
+		}
+		if err := m.ObserveUser(txCtx, otherUser, user); err != nil {
+			return err
+		}
+
+		return runCleanups(txCtx, cleanups)
+	})
+	if err != nil {
+		return fmt.Errorf("observe each other transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoUserAdapter) BlockUser(
+	ctx context.Context,
+	user, userToBlock id.ID,
+	cleanups ...func(ctx context.Context) error,
+) error {
+	err := m.withTransaction(ctx, func(txCtx context.Context) error {
+		update := bson.M{
+			"$addToSet": bson.M{
+				"blocked_users": userToBlock,
+			},
+			"$pull": bson.M{
+				"subscribed_users": userToBlock,
+			},
+			"$unset": bson.M{
+				subscribedSinceKey(userToBlock): "",
+			},
+		}
+		if _, err := m.coll.UpdateOne(txCtx, withUserId(user), update); err != nil {
+			return fmt.Errorf("block user: %w", err)
+		}
+
+		if err := m.UnobserveUser(txCtx, userToBlock, user); err != nil {
+			return err
+		}
+
+		return runCleanups(txCtx, cleanups)
+	})
+	if err != nil {
+		return fmt.Errorf("block user transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoUserAdapter) UnblockUser(ctx context.Context, user, userToUnblock id.ID) error {
+	update := bson.M{
+		"$pull": bson.M{
+			"blocked_users": userToUnblock,
+		},
+	}
+
+	_, err := m.coll.UpdateOne(ctx, withUserId(user), update)
+	if err != nil {
+		return fmt.Errorf("unblock user: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoUserAdapter) DeleteUser(
+	ctx context.Context,
+	user id.ID,
+	cleanups ...func(ctx context.Context) error,
+) error {
+	err := m.withTransaction(ctx, func(txCtx context.Context) error {
+		res, err := m.coll.DeleteOne(txCtx, withUserId(user))
+		if err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+		if res.DeletedCount == 0 {
+			return ErrUserNotExists
+		}
+
+		filter := bson.M{
+			"$or": bson.A{
+				bson.M{"subscribed_users": user},
+				bson.M{"blocked_users": user},
+				bson.M{locationPrecisionKey(user): bson.M{"$exists": true}},
+			},
+		}
+		update := bson.M{
+			"$pull": bson.M{
+				"subscribed_users": user,
+				"blocked_users":    user,
+			},
+			"$unset": bson.M{
+				subscribedSinceKey(user):   "",
+				locationPrecisionKey(user): "",
+			},
+		}
+		if _, err := m.coll.UpdateMany(txCtx, filter, update); err != nil {
+			return fmt.Errorf("remove user from subscriptions: %w", err)
+		}
+
+		return runCleanups(txCtx, cleanups)
+	})
+	if err != nil {
+		return fmt.Errorf("delete user transaction: %w", err)
+	}
+
+	return nil
+}
+
+// withTransaction runs fn in a transaction, ctx passed to fn is bound to the transaction
+func (m *mongoUserAdapter) withTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	session, err := m.coll.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(txCtx mongo.SessionContext) (any, error) {
+		return nil, fn(txCtx)
+	})
+
+	return err //nolint:wrapcheck // wrapped by callers
+}
+
+func runCleanups(ctx context.Context, cleanups []func(ctx context.Context) error) error {
+	for _, cleanup := range cleanups {
+		if err := cleanup(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 var _ Adapter = (*mongoUserAdapter)(nil)
@@ -0,0 +1,119 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/secure/precis"
+)
+
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 32
+)
+
+var (
+	ErrInvalidUsername  = errors.New("invalid username")
+	ErrUsernameReserved = errors.New("username is reserved")
+)
+
+// NormalizeUsername returns the username in a canonical form (RFC 8265, case preserved):
+// unicode NFC normalization, full-width characters mapped to half-width ones.
+func NormalizeUsername(name string) (string, error) {
+	normalized, err := precis.UsernameCasePreserved.String(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidUsername, err.Error())
+	}
+
+	return normalized, nil
+}
+
+// UsernameKey returns case-insensitive key of the username, equal for usernames considered the same.
+func UsernameKey(name string) string {
+	key, err := precis.UsernameCaseMapped.String(name)
+	if err != nil {
+		return strings.ToLower(name)
+	}
+
+	return key
+}
+
+// ValidateUsername checks the character and length policy of (normalized) username:
+// 3-32 letters, digits, `.`, `_` or `-`, starting with a letter or a digit.
+// Letters must be of a single script, so lookalikes like Cyrillic "а" in "аdmin" cannot impersonate other users.
+func ValidateUsername(name string) error {
+	length := utf8.RuneCountInString(name)
+	if length < UsernameMinLength || length > UsernameMaxLength {
+		return fmt.Errorf("%w: must have %d-%d characters", ErrInvalidUsername, UsernameMinLength, UsernameMaxLength)
+	}
+
+	nameScript := ""
+	for i, r := range []rune(name) {
+		if unicode.IsLetter(r) {
+			s := script(r)
+			if nameScript != "" && s != "" && s != nameScript {
+				return fmt.Errorf("%w: letters of different scripts (%s and %s)", ErrInvalidUsername, nameScript, s)
+			}
+			if s != "" {
+				nameScript = s
+			}
+			continue
+		}
+		if unicode.IsDigit(r) {
+			continue
+		}
+		if i > 0 && (r == '.' || r == '_' || r == '-') {
+			continue
+		}
+		return fmt.Errorf("%w: unexpected character %q", ErrInvalidUsername, r)
+	}
+
+	return nil
+}
+
+// script returns the name of the letter script, empty for letters used with many scripts (e.g. Japanese `ー`).
+// Japanese scripts are considered as one, they are mixed in names.
+func script(r rune) string {
+	for name, table := range unicode.Scripts {
+		if !unicode.Is(table, r) {
+			continue
+		}
+		switch name {
+		case "Common", "Inherited":
+			return ""
+		case "Hiragana", "Katakana":
+			return "Han"
+		}
+		return name
+	}
+
+	return ""
+}
+
+// ReservedUsernames is a list of usernames which cannot be registered.
+type ReservedUsernames struct {
+	keys map[string]struct{}
+}
+
+func NewReservedUsernames(names []string) *ReservedUsernames {
+	keys := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			keys[UsernameKey(name)] = struct{}{}
+		}
+	}
+
+	return &ReservedUsernames{keys}
+}
+
+// Check returns ErrUsernameReserved if the username is reserved (case-insensitive).
+func (r *ReservedUsernames) Check(name string) error {
+	if _, ok := r.keys[UsernameKey(name)]; ok {
+		return ErrUsernameReserved
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package users
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_ValidateUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		valid    bool
+	}{
+		{name: "latin", username: "alice.smith-99", valid: true},
+		{name: "cyrillic", username: "наташа", valid: true},
+		{name: "greek", username: "σωκράτης", valid: true},
+		{name: "japanese", username: "さくらサクラ桜", valid: true},
+		{name: "japanese with prolonged sound mark", username: "ラーメン", valid: true},
+		{name: "digits only", username: "123", valid: true},
+		{name: "too short", username: "al"},
+		{name: "too long", username: "abcdefghijklmnopqrstuvwxyz0123456"},
+		{name: "starts with dot", username: ".alice"},
+		{name: "space", username: "alice smith"},
+		{name: "cyrillic a in latin name", username: "аdmin"},
+		{name: "greek o in latin name", username: "rοot"},
+		{name: "latin and han", username: "alice桜"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUsername(tt.username)
+			if tt.valid && err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidUsername) {
+				t.Fatalf("invalid username %q accepted, err: %v", tt.username, err)
+			}
+		})
+	}
+}
@@ -3,33 +3,101 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
+	"strconv"
 	"time"
+	"whereiseveryone/internal/attempts"
+	"whereiseveryone/internal/oidcstates"
+	"whereiseveryone/internal/resets"
+	"whereiseveryone/internal/tokens"
 	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi"
+	"whereiseveryone/internal/webapi/binder"
 	"whereiseveryone/internal/webapi/jsonerr"
 	"whereiseveryone/pkg/crypto"
 	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/iif"
 	"whereiseveryone/pkg/jwt"
+	"whereiseveryone/pkg/mail"
+	"whereiseveryone/pkg/oidc"
 	"whereiseveryone/pkg/timer"
+	"whereiseveryone/pkg/totp"
+)
+
+// Config is a configuration of auth endpoints
+type Config struct {
+	// ResetValidity is a validity of password reset codes
+	ResetValidity time.Duration
+	// AccountPolicy limits failed log in attempts per account
+	AccountPolicy attempts.Policy
+	// IPPolicy limits failed log in attempts per client IP
+	IPPolicy attempts.Policy
+	// ReservedUsernames cannot be used by new users
+	ReservedUsernames *users.ReservedUsernames
+	// OIDCProviders are configured OpenID Connect providers by their names
+	OIDCProviders map[string]*oidc.Provider
+	// OIDCStateValidity is a time the user has to log in with the provider
+	OIDCStateValidity time.Duration
+}
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrTooManyAttempts    = errors.New("too many failed attempts, try again later")
 )
 
 type mux struct {
-	userAdapter users.Adapter
-	timer       timer.Timer
-	jwt         *jwt.JWT
+	userAdapter   users.Adapter
+	revokedTokens tokens.Adapter
+	resets        resets.Adapter
+	attempts      attempts.Adapter
+	oidcStates    oidcstates.Adapter
+	sender        mail.Sender
+	hasher        *crypto.Hasher
+	otp           totp.TOTP
+	timer         timer.Timer
+	jwt           *jwt.JWT
+	config        Config
+
+	// dummyHash is verified when user doesn't exist, so the response time doesn't reveal it
+	dummyHash string
 }
 
 func NewMux(
 	userAdapter users.Adapter,
+	revokedTokens tokens.Adapter,
+	resets resets.Adapter,
+	attempts attempts.Adapter,
+	oidcStates oidcstates.Adapter,
+	sender mail.Sender,
+	hasher *crypto.Hasher,
 	timer timer.Timer,
 	jwt *jwt.JWT,
-) *mux {
-	return &mux{userAdapter, timer, jwt}
+	config Config,
+) (*mux, error) {
+	dummyHash, err := hasher.Hash(id.NewID().Hex())
+	if err != nil {
+		return nil, fmt.Errorf("hash dummy password: %w", err)
+	}
+
+	return &mux{
+		userAdapter, revokedTokens, resets, attempts, oidcStates, sender,
+		hasher, totp.New(), timer, jwt, config, dummyHash,
+	}, nil
 }
 
-func (m *mux) Route(g *echo.Group, _ echo.MiddlewareFunc) {
+func (m *mux) Route(g *echo.Group, authMiddleware echo.MiddlewareFunc) {
 	g.POST("/signup", m.signUp)
 	g.POST("/login", m.logIn)
+	g.POST("/2fa", m.logInTwoFactor)
+	g.POST("/refresh", m.refresh)
+	g.POST("/logout", m.logOut, authMiddleware)
+	g.POST("/logout-all", m.logOutAll, authMiddleware, webapi.RequireScopes(webapi.ScopeProfileWrite))
+	g.POST("/password-reset/request", m.requestPasswordReset)
+	g.POST("/password-reset/confirm", m.confirmPasswordReset)
+	g.GET("/oidc/:provider/login", m.oidcLogIn)
+	g.POST("/oidc/:provider/link", m.oidcLink, authMiddleware, webapi.RequireScopes(webapi.ScopeProfileWrite))
+	g.GET("/oidc/:provider/callback", m.oidcCallback)
 }
 
 // signUp
@@ -42,7 +110,7 @@ func (m *mux) Route(g *echo.Group, _ echo.MiddlewareFunc) {
 // @param userDetails body signUpRequest true "sign up details"
 // @success 200 {object} authResponse
 // @failure 400 {object} jsonerr.JSONError "invalid request"
-// @failure 409 {object} jsonerr.JSONError "conflict (user with such a name exists)
+// @failure 409 {object} jsonerr.JSONError "conflict (user with such a name exists or the name is reserved)"
 // @failure 500 {object} jsonerr.JSONError "internal server error"
 // @router /auth/signup [POST]
 func (m *mux) signUp(c echo.Context) error {
@@ -57,7 +125,16 @@ func (m *mux) signUp(c echo.Context) error {
 		return jsonerr.EchoInvalidRequestError(err).Echo(c)
 	}
 
-	encPass, err := crypto.HashPassword(request.Password)
+	// the username policy is checked by the validator
+	username, err := users.NormalizeUsername(request.Username)
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+	if err := m.config.ReservedUsernames.Check(username); err != nil {
+		return jsonerr.EchoConflictError(err).Echo(c)
+	}
+
+	encPass, err := m.hasher.Hash(request.Password)
 	if err != nil {
 		return jsonerr.EchoInvalidRequestError(err).Echo(c)
 	}
@@ -65,12 +142,12 @@ func (m *mux) signUp(c echo.Context) error {
 	u := users.User{
 		ID: id.ID{}, // stub
 		Auth: users.Auth{
-			Username:     request.Username,
-			Password:     encPass,
-			Token:        "",
-			RefreshToken: "",
-			CreatedAt:    m.timer.Now(),
-			UpdatedAt:    m.timer.Now(),
+			Username:  username,
+			Password:  encPass,
+			Email:     request.Email,
+			Sessions:  []users.Session{},
+			CreatedAt: m.timer.Now(),
+			UpdatedAt: m.timer.Now(),
 		},
 	}
 
@@ -82,34 +159,31 @@ func (m *mux) signUp(c echo.Context) error {
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
-	token, refresh, err := m.jwt.GenerateTokens(u.Auth.Username, u.ID)
+	response, err := m.newSession(reqCtx, c, u, request.DeviceName, webapi.AllScopes)
 	if err != nil {
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
-	if err := m.userAdapter.UpdateTokens(reqCtx, u.ID, &token, &refresh); err != nil {
-		return jsonerr.EchoInternalError(err).Echo(c)
-	}
-
-	return c.JSON(200, authResponse{
-		ID:           u.ID.Hex(),
-		Token:        token,
-		RefreshToken: refresh,
-	})
+	return c.JSON(200, response)
 }
 
 // logIn
 //
 // @summary log in
-// @description logs in as an exiting users using login and passowrd
+// @description logs in as an exiting users using login and passowrd.
+// @description Failed attempts are counted per account and per IP, after too many of them log in is locked
+// @description for some time (doubled with every next failed attempt).
+// @description If the user has 2FA enabled, `202` with a short-lived challenge token is returned instead of tokens,
+// @description it has to be exchanged for tokens with the second factor (see /auth/2fa).
 // @tags auth
 // @accept json
 // @produces json
 // @param userDetails body logInRequest true "login details"
 // @success 200 {object} authResponse
+// @success 202 {object} challengeResponse
 // @failure 400 {object} jsonerr.JSONError "invalid request"
-// @failure 403 {object} jsonerr.JSONError "forbidden (invalid password)"
-// @failure 404 {object} jsonerr.JSONError "user not exists"
+// @failure 401 {object} jsonerr.JSONError "invalid username or password"
+// @failure 429 {object} jsonerr.JSONError "too many failed attempts (see Retry-After header)"
 // @failure 500 {object} jsonerr.JSONError "internal server error"
 // @router /auth/login [POST]
 func (m *mux) logIn(c echo.Context) error {
@@ -124,32 +198,307 @@ func (m *mux) logIn(c echo.Context) error {
 		return jsonerr.EchoInvalidRequestError(err).Echo(c)
 	}
 
+	// the attempt is counted as failed before the password is verified, so parallel attempts can't get over the limit
+	accountKey, ipKey := attempts.AccountKey(users.UsernameKey(request.Username)), attempts.IPKey(c.RealIP())
+	account, ip, attemptErr := m.beginAttempt(reqCtx, c, accountKey, ipKey)
+	if attemptErr != nil {
+		return attemptErr.Echo(c)
+	}
+
 	u, err := m.userAdapter.GetUserByUsername(reqCtx, request.Username)
-	if err != nil {
-		if errors.Is(err, users.ErrUserNotExists) {
-			return jsonerr.EchoNotFoundError(err).Echo(c)
+	if err != nil && !errors.Is(err, users.ErrUserNotExists) {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	userExists := err == nil
+
+	// unknown user and invalid password are not distinguishable (the password is always verified)
+	verifyErr := m.hasher.Verify(iif.IfElse(userExists, u.Auth.Password, m.dummyHash), request.Password)
+	if !userExists || verifyErr != nil {
+		return jsonerr.EchoUnauthorizedError(ErrInvalidCredentials).Echo(c)
+	}
+
+	if err := m.attempts.Release(reqCtx, ip); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	// upgrade the hash if hashing algorithm or its parameters were changed
+	if m.hasher.NeedsRehash(u.Auth.Password) {
+		if err := m.rehashPassword(reqCtx, u.ID, request.Password); err != nil {
+			c.Logger().Errorf("rehash password: %v", err) // the old hash is still valid
+		}
+	}
+
+	if u.Auth.TwoFactor.IsEnabled() {
+		challenge, err := m.jwt.GenerateChallengeToken(u.Auth.Username, u.ID, requestedScopes(request.Scopes))
+		if err != nil {
+			return jsonerr.EchoInternalError(err).Echo(c)
+		}
+		// failed attempts are reset after the second factor is verified
+		if err := m.attempts.Release(reqCtx, account); err != nil {
+			return jsonerr.EchoInternalError(err).Echo(c)
 		}
+		return c.JSON(202, challengeResponse{ChallengeToken: challenge})
+	}
+
+	if err := m.attempts.Reset(reqCtx, accountKey); err != nil {
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
-	if err = crypto.VerifyPassword(u.Auth.Password, request.Password); err != nil {
-		return jsonerr.EchoForbiddenError().Echo(c)
+	response, err := m.newSession(reqCtx, c, u, request.DeviceName, requestedScopes(request.Scopes))
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(200, response)
+}
+
+// refresh
+//
+// @summary refresh tokens
+// @description issues a new pair of tokens in exchange for a valid refresh token.
+// @description Each refresh token can be used only once, reusing already rotated token revokes the session.
+// @tags auth
+// @accept json
+// @produces json
+// @param refreshDetails body refreshRequest true "refresh details"
+// @success 200 {object} authResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 401 {object} jsonerr.JSONError "invalid, expired or reused refresh token"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/refresh [POST]
+func (m *mux) refresh(c echo.Context) error {
+	reqCtx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(60)*time.Second)
+	defer cancel()
+
+	var request refreshRequest
+	if err := c.Bind(&request); err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+	if err := c.Validate(request); err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	claims, err := m.jwt.ValidateRefreshToken(request.RefreshToken)
+	if err != nil {
+		return jsonerr.EchoUnauthorizedError(err).Echo(c)
+	}
+
+	userID, err := id.FromString(claims.Subject)
+	if err != nil || userID == id.ZeroID {
+		return jsonerr.EchoUnauthorizedError(errors.New("invalid refresh token subject")).Echo(c)
+	}
+	sessionID, err := id.FromString(claims.SessionID)
+	if err != nil || sessionID == id.ZeroID {
+		return jsonerr.EchoUnauthorizedError(errors.New("invalid refresh token session")).Echo(c)
 	}
 
-	token, refresh, err := m.jwt.GenerateTokens(u.Auth.Username, u.ID)
+	u, err := m.userAdapter.GetUser(reqCtx, userID)
 	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoUnauthorizedError(err).Echo(c)
+		}
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
-	if err := m.userAdapter.UpdateTokens(reqCtx, u.ID, &token, &refresh); err != nil {
+	// refreshed tokens keep the scopes, tokens issued before scopes were introduced get all of them
+	scopes := iif.IfElse(claims.Scopes == nil, webapi.AllScopes, claims.Scopes)
+	token, refresh, err := m.jwt.GenerateTokens(u.Auth.Username, u.ID, sessionID, scopes)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	err = m.userAdapter.RotateTokens(reqCtx, u.ID, sessionID, request.RefreshToken, token, refresh)
+	if err != nil {
+		if errors.Is(err, users.ErrRefreshTokenReused) {
+			// The token is signed by us, but it was already rotated (or the session was removed).
+			// Treat it as stolen and revoke the whole session.
+			if err := m.revokeSession(reqCtx, u.ID, sessionID); err != nil {
+				return jsonerr.EchoInternalError(err).Echo(c)
+			}
+			return jsonerr.EchoUnauthorizedError(users.ErrRefreshTokenReused).Echo(c)
+		}
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
 	return c.JSON(200, authResponse{
 		ID:           u.ID.Hex(),
+		SessionID:    sessionID.Hex(),
 		Token:        token,
 		RefreshToken: refresh,
 	})
 }
 
-// TODO: Add refresh-token endpoint.
+// logOut
+//
+// @summary log out
+// @description revokes the session (device) the token was issued for
+// @tags auth
+// @security Bearer
+// @success 204
+// @failure 401 {object} jsonerr.JSONError "token revoked"
+// @failure 403 {object} jsonerr.JSONError "forbidden (invalid token)"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/logout [POST]
+func (m *mux) logOut(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	sessionID, err := id.FromString(request.TokenData().SessionID)
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if err := m.revokeSession(request.Context(), request.UserID(), sessionID); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// logOutAll
+//
+// @summary log out everywhere
+// @description revokes all tokens issued for the user so far (all sessions)
+// @tags auth
+// @security Bearer
+// @success 204
+// @failure 401 {object} jsonerr.JSONError "token revoked"
+// @failure 403 {object} jsonerr.JSONError "forbidden (invalid token)"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/logout-all [POST]
+func (m *mux) logOutAll(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	if err := m.revokeAll(request.Context(), request.UserID()); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+func (m *mux) rehashPassword(ctx context.Context, userID id.ID, password string) error {
+	encPass, err := m.hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	if err := m.userAdapter.UpdatePassword(ctx, userID, encPass); err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+
+	return nil
+}
+
+// newSession creates a new session for the user and issues its tokens with the scopes.
+func (m *mux) newSession(
+	ctx context.Context,
+	c echo.Context,
+	u users.User,
+	deviceName string,
+	scopes []string,
+) (authResponse, error) {
+	sessionID := id.NewID()
+	token, refresh, err := m.jwt.GenerateTokens(u.Auth.Username, u.ID, sessionID, scopes)
+	if err != nil {
+		return authResponse{}, fmt.Errorf("generate tokens: %w", err)
+	}
+
+	now := m.timer.Now()
+	session := users.Session{
+		ID:           sessionID,
+		DeviceName:   deviceName,
+		UserAgent:    c.Request().UserAgent(),
+		IP:           c.RealIP(),
+		Token:        token,
+		RefreshToken: refresh,
+		CreatedAt:    now,
+		LastUsedAt:   now,
+	}
+	if err := m.userAdapter.NewSession(ctx, u.ID, session); err != nil {
+		return authResponse{}, fmt.Errorf("create session: %w", err)
+	}
+
+	return authResponse{
+		ID:           u.ID.Hex(),
+		SessionID:    sessionID.Hex(),
+		Token:        token,
+		RefreshToken: refresh,
+	}, nil
+}
+
+// beginAttempt counts the attempt of the account and the IP as failed in advance (see attempts.Adapter.Begin).
+// Returns too many requests error with Retry-After header if any of them is locked.
+func (m *mux) beginAttempt(
+	ctx context.Context,
+	c echo.Context,
+	accountKey, ipKey string,
+) (attempts.Attempts, attempts.Attempts, *jsonerr.JSONError) {
+	account, err := m.attempts.Begin(ctx, accountKey, m.config.AccountPolicy)
+	if err != nil {
+		return attempts.Attempts{}, attempts.Attempts{}, m.attemptError(c, account, err)
+	}
+
+	ip, err := m.attempts.Begin(ctx, ipKey, m.config.IPPolicy)
+	if err != nil {
+		// the attempt is not made
+		if releaseErr := m.attempts.Release(ctx, account); releaseErr != nil {
+			return attempts.Attempts{}, attempts.Attempts{}, jsonerr.EchoInternalError(releaseErr)
+		}
+		return attempts.Attempts{}, attempts.Attempts{}, m.attemptError(c, ip, err)
+	}
+
+	return account, ip, nil
+}
+
+func (m *mux) attemptError(c echo.Context, locked attempts.Attempts, err error) *jsonerr.JSONError {
+	if !errors.Is(err, attempts.ErrLocked) {
+		return jsonerr.EchoInternalError(err)
+	}
+
+	retryAfter := max(locked.LockedUntil.Sub(m.timer.Now()), 0)
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+
+	return jsonerr.EchoTooManyRequestsError(ErrTooManyAttempts)
+}
+
+// revokeSession revokes all tokens issued for the session and removes it.
+func (m *mux) revokeSession(ctx context.Context, userID, sessionID id.ID) error {
+	if err := m.revokedTokens.RevokeSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("revoke session tokens: %w", err)
+	}
+
+	err := m.userAdapter.DeleteSession(ctx, userID, sessionID)
+	if err != nil && !errors.Is(err, users.ErrSessionNotExists) {
+		return fmt.Errorf("delete session: %w", err)
+	}
+
+	return nil
+}
+
+// revokeAll revokes all tokens issued for the user so far and removes all sessions.
+func (m *mux) revokeAll(ctx context.Context, userID id.ID) error {
+	if err := m.revokedTokens.RevokeUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("revoke user tokens: %w", err)
+	}
+
+	if err := m.userAdapter.DeleteSessions(ctx, userID); err != nil {
+		return fmt.Errorf("delete sessions: %w", err)
+	}
+
+	return nil
+}
+
+// requestedScopes returns the scopes requested on log in, all scopes if none are requested
+func requestedScopes(scopes []string) []string {
+	if len(scopes) == 0 {
+		return webapi.AllScopes
+	}
+	return scopes
+}
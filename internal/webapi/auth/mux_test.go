@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"whereiseveryone/internal/attempts"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/internal/webapitest"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
+)
+
+func Test_RefreshReuse(t *testing.T) {
+	tm := &webapitest.Timer{Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	j := jwt.NewJWT(tm, jwt.NewHMACKeySet([]byte("secret")), jwt.Config{
+		Issuer:          "issuer",
+		Audience:        "audience",
+		AccessValidity:  time.Hour,
+		RefreshValidity: 24 * time.Hour,
+		Leeway:          time.Minute,
+	})
+
+	userID, sessionID := id.NewID(), id.NewID()
+	token, refresh, err := j.GenerateTokens("alice", userID, sessionID, nil)
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+	alice := &users.User{
+		ID: userID,
+		Auth: users.Auth{
+			Username: "alice",
+			Sessions: []users.Session{{ID: sessionID, Token: token, RefreshToken: refresh}},
+		},
+	}
+	revoked := &webapitest.Tokens{}
+
+	m, err := NewMux(webapitest.NewUsers(alice), revoked, nil, nil, nil, nil, crypto.NewBcryptHasher(4), tm, j, Config{})
+	if err != nil {
+		t.Fatalf("new mux: %v", err)
+	}
+	e := webapitest.NewEcho()
+	m.Route(e.Group("/auth"), webapitest.Auth)
+
+	doRefresh := func(refreshToken string) int {
+		t.Helper()
+		rec := webapitest.Request(e, http.MethodPost, "/auth/refresh", `{"refresh_token":"`+refreshToken+`"}`, id.ZeroID)
+		if rec.Code == http.StatusOK {
+			var resp authResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			refresh = resp.RefreshToken
+		}
+		return rec.Code
+	}
+
+	// tokens must differ from the original ones
+	tm.Time = tm.Time.Add(time.Second)
+	stolen := refresh
+	if code := doRefresh(refresh); code != http.StatusOK {
+		t.Fatalf("refresh: status %d", code)
+	}
+	if refresh == stolen {
+		t.Fatalf("refresh token was not rotated")
+	}
+	if len(revoked.RevokedSessions) != 0 {
+		t.Fatalf("session revoked on a valid refresh")
+	}
+
+	// the rotated away token is presented again, e.g. by a thief
+	if code := doRefresh(stolen); code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh: status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if len(revoked.RevokedSessions) != 1 || revoked.RevokedSessions[0] != sessionID {
+		t.Fatalf("revoked sessions %v, want [%v]", revoked.RevokedSessions, sessionID)
+	}
+	if len(alice.Auth.Sessions) != 0 {
+		t.Fatalf("session was not deleted")
+	}
+
+	// the legitimate client is logged out as well
+	if code := doRefresh(refresh); code != http.StatusUnauthorized {
+		t.Fatalf("refresh after revocation: status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func Test_LogIn_ConcurrentFailures(t *testing.T) {
+	tm := &webapitest.Timer{Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	j := jwt.NewJWT(tm, jwt.NewHMACKeySet([]byte("secret")), jwt.Config{
+		AccessValidity:  time.Hour,
+		RefreshValidity: 24 * time.Hour,
+	})
+
+	hasher := crypto.NewBcryptHasher(4)
+	password, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	alice := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "alice", Password: password}}
+	counters := webapitest.NewAttempts(tm)
+
+	m, err := NewMux(webapitest.NewUsers(alice), &webapitest.Tokens{}, nil, counters, nil, nil, hasher, tm, j, Config{
+		AccountPolicy: attempts.Policy{
+			FreeAttempts: 3,
+			BaseLockout:  30 * time.Second,
+			MaxLockout:   time.Hour,
+			Window:       time.Hour,
+		},
+		IPPolicy: attempts.Policy{FreeAttempts: 100, BaseLockout: time.Second, MaxLockout: time.Hour, Window: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("new mux: %v", err)
+	}
+	e := webapitest.NewEcho()
+	m.Route(e.Group("/auth"), webapitest.Auth)
+
+	logIn := func(password string) int {
+		return webapitest.Request(e, http.MethodPost, "/auth/login",
+			`{"username":"alice","password":"`+password+`"}`, id.ZeroID).Code
+	}
+
+	// all the attempts are verified at once, only the free ones and the one locking the account get through
+	codes := make(chan int, 20)
+	var wg sync.WaitGroup
+	for range cap(codes) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- logIn("wrong")
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusUnauthorized] != 4 || counts[http.StatusTooManyRequests] != 16 {
+		t.Fatalf("unexpected responses by status: %v", counts)
+	}
+
+	// the lockout ends, the correct password resets the account counter
+	tm.Time = tm.Time.Add(31 * time.Second)
+	if code := logIn("correct horse"); code != http.StatusOK {
+		t.Fatalf("log in after lockout: status %d", code)
+	}
+	if _, ok := counters.Counters[attempts.AccountKey(users.UsernameKey("alice"))]; ok {
+		t.Fatalf("account counter not reset after a successful log in")
+	}
+}
+
+func Test_SignUp_UsernamePolicy(t *testing.T) {
+	tm := &webapitest.Timer{Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	m, err := NewMux(webapitest.NewUsers(), nil, nil, nil, nil, nil, crypto.NewBcryptHasher(4), tm, nil, Config{
+		ReservedUsernames: users.NewReservedUsernames([]string{"admin"}),
+	})
+	if err != nil {
+		t.Fatalf("new mux: %v", err)
+	}
+	e := webapitest.NewEcho()
+	m.Route(e.Group("/auth"), webapitest.Auth)
+
+	tests := []struct {
+		username string
+		want     int
+	}{
+		{username: "ab", want: http.StatusBadRequest},
+		{username: "-alice", want: http.StatusBadRequest},
+		{username: "al ice", want: http.StatusBadRequest},
+		{username: "аdmin", want: http.StatusBadRequest}, // Cyrillic "а"
+		{username: "Admin", want: http.StatusConflict},
+		{username: "ａｄｍｉｎ", want: http.StatusConflict}, // full-width, normalized
+	}
+	for _, tt := range tests {
+		body := `{"username":"` + tt.username + `","password":"password"}`
+		if rec := webapitest.Request(e, http.MethodPost, "/auth/signup", body, id.ZeroID); rec.Code != tt.want {
+			t.Errorf("sign up as %q: status %d, want %d, body: %s", tt.username, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+}
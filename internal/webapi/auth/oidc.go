@@ -0,0 +1,359 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"whereiseveryone/internal/oidcstates"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/iif"
+	"whereiseveryone/pkg/oidc"
+	"whereiseveryone/pkg/pointers"
+)
+
+const (
+	// oidcUsernameAttempts is a number of usernames tried for a new user before giving up
+	oidcUsernameAttempts = 5
+	// oidcBindingCookie binds the authorization to the client which started it, it's checked by the callback
+	oidcBindingCookie = "oidc_binding"
+	// oidcBindingLength is a length of random bytes of the binding cookie
+	oidcBindingLength = 32
+)
+
+var (
+	ErrUnknownProvider       = errors.New("unknown identity provider")
+	ErrProviderAuthorization = errors.New("identity provider authorization failed")
+	ErrStateNotBound         = errors.New("authorization was started by another client")
+)
+
+// oidcLogIn
+//
+// @summary log in with OIDC provider
+// @description redirects to the provider authorization page (authorization code flow with PKCE).
+// @description The provider redirects back to /auth/oidc/{provider}/callback,
+// @description the callback must be opened by the same browser (`oidc_binding` cookie is set).
+// @tags auth
+// @param provider path string true "provider name"
+// @param device_name query string false "name of the device, used to identify the session"
+// @success 302
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "unknown provider"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/oidc/{provider}/login [GET]
+func (m *mux) oidcLogIn(c echo.Context) error {
+	request, bindErr := binder.BindRequest[oidcLogInRequest](c, false)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	authURL, err := m.startAuthorization(request.Context(), c, request.Request.Provider, request.Request.DeviceName, nil)
+	if err != nil {
+		return oidcError(err).Echo(c)
+	}
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// oidcLink
+//
+// @summary link OIDC identity
+// @description returns the provider authorization page URL, after the authorization
+// @description the provider identity is linked to the logged user (the callback returns 204).
+// @description The URL must be opened by the browser which sent this request (`oidc_binding` cookie is set),
+// @description the callback opened by anyone else is rejected.
+// @tags auth
+// @security Bearer
+// @produce json
+// @param provider path string true "provider name"
+// @success 200 {object} oidcLinkResponse
+// @failure 403 {object} jsonerr.JSONError "forbidden (invalid token)"
+// @failure 404 {object} jsonerr.JSONError "unknown provider"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/oidc/{provider}/link [POST]
+func (m *mux) oidcLink(c echo.Context) error {
+	request, bindErr := binder.BindRequest[oidcLinkRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	linkUserID := pointers.Pointer(request.UserID())
+	authURL, err := m.startAuthorization(request.Context(), c, request.Request.Provider, "", linkUserID)
+	if err != nil {
+		return oidcError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, oidcLinkResponse{URL: authURL})
+}
+
+// oidcCallback
+//
+// @summary OIDC callback
+// @description completes the provider authorization. Logs in the user with the linked identity,
+// @description a new user is created if the identity is not linked to any user.
+// @description For link authorizations the identity is linked and 204 is returned.
+// @tags auth
+// @produce json
+// @param provider path string true "provider name"
+// @param code query string false "authorization code"
+// @param state query string true "state"
+// @success 200 {object} authResponse
+// @success 202 {object} challengeResponse
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request (invalid or expired state, or another client started it)"
+// @failure 401 {object} jsonerr.JSONError "provider authorization failed"
+// @failure 404 {object} jsonerr.JSONError "unknown provider"
+// @failure 409 {object} jsonerr.JSONError "identity is already linked"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/oidc/{provider}/callback [GET]
+func (m *mux) oidcCallback(c echo.Context) error {
+	request, bindErr := binder.BindRequest[oidcCallbackRequest](c, false)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+	ctx := request.Context()
+
+	provider, ok := m.config.OIDCProviders[request.Request.Provider]
+	if !ok {
+		return jsonerr.EchoNotFoundError(ErrUnknownProvider).Echo(c)
+	}
+
+	state, err := m.oidcStates.Consume(ctx, request.Request.Provider, crypto.HashToken(request.Request.State))
+	if err != nil {
+		return oidcError(err).Echo(c)
+	}
+	if err := checkBinding(c, state); err != nil {
+		return oidcError(err).Echo(c)
+	}
+
+	if request.Request.Error != "" || request.Request.Code == "" {
+		err := fmt.Errorf("%w: %s", ErrProviderAuthorization, request.Request.Error)
+		return jsonerr.EchoUnauthorizedError(err).Echo(c)
+	}
+
+	token, err := provider.Exchange(ctx, request.Request.Code, state.CodeVerifier)
+	if err != nil {
+		c.Logger().Errorf("oidc code exchange: %v", err)
+		return jsonerr.EchoUnauthorizedError(ErrProviderAuthorization).Echo(c)
+	}
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		c.Logger().Errorf("oidc id token verification: %v", err)
+		return jsonerr.EchoUnauthorizedError(ErrProviderAuthorization).Echo(c)
+	}
+
+	identity := users.Identity{
+		Provider: request.Request.Provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		LinkedAt: m.timer.Now(),
+	}
+
+	if state.LinkUserID != nil {
+		if err := m.userAdapter.LinkIdentity(ctx, *state.LinkUserID, identity); err != nil {
+			return oidcError(err).Echo(c)
+		}
+		return c.NoContent(204)
+	}
+
+	u, err := m.userAdapter.GetUserByIdentity(ctx, identity.Provider, identity.Subject)
+	if errors.Is(err, users.ErrUserNotExists) {
+		u, err = m.newOIDCUser(ctx, identity, claims)
+	}
+	if err != nil {
+		return oidcError(err).Echo(c)
+	}
+
+	if u.Auth.TwoFactor.IsEnabled() {
+		challenge, err := m.jwt.GenerateChallengeToken(u.Auth.Username, u.ID, webapi.AllScopes)
+		if err != nil {
+			return jsonerr.EchoInternalError(err).Echo(c)
+		}
+		return c.JSON(202, challengeResponse{ChallengeToken: challenge})
+	}
+
+	response, err := m.newSession(ctx, c, u, state.DeviceName, webapi.AllScopes)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(200, response)
+}
+
+// startAuthorization stores a new state and returns the provider authorization URL.
+func (m *mux) startAuthorization(
+	ctx context.Context,
+	c echo.Context,
+	providerName, deviceName string,
+	linkUserID *id.ID,
+) (string, error) {
+	provider, ok := m.config.OIDCProviders[providerName]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := crypto.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := crypto.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	binding, err := crypto.RandomToken(oidcBindingLength)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", err
+	}
+
+	now := m.timer.Now()
+	expiresAt := now.Add(m.config.OIDCStateValidity)
+	err = m.oidcStates.Create(ctx, oidcstates.State{
+		StateHash:    crypto.HashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		DeviceName:   deviceName,
+		LinkUserID:   linkUserID,
+		BindingHash:  crypto.HashToken(binding),
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// the cookie is sent to /auth/oidc/{provider}/callback only
+	c.SetCookie(&http.Cookie{
+		Name:     oidcBindingCookie,
+		Value:    binding,
+		Path:     path.Dir(c.Request().URL.Path),
+		Expires:  expiresAt,
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return authURL, nil
+}
+
+// checkBinding returns ErrStateNotBound if the callback isn't sent by the client which started the authorization.
+// Otherwise a link URL sent to somebody else would link their identity to the user who started it.
+func checkBinding(c echo.Context, state oidcstates.State) error {
+	cookie, err := c.Cookie(oidcBindingCookie)
+	if err != nil || cookie.Value == "" ||
+		subtle.ConstantTimeCompare([]byte(crypto.HashToken(cookie.Value)), []byte(state.BindingHash)) != 1 {
+		return ErrStateNotBound
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oidcBindingCookie,
+		Path:     path.Dir(c.Request().URL.Path),
+		MaxAge:   -1,
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// newOIDCUser creates a user for the identity, username is based on the provider claims.
+// Such a user has no password (it can be set with the password reset if the email is verified).
+func (m *mux) newOIDCUser(ctx context.Context, identity users.Identity, claims oidc.Claims) (users.User, error) {
+	base := oidcUsername(claims)
+	now := m.timer.Now()
+
+	for i := range oidcUsernameAttempts {
+		username := base
+		if i > 0 || m.config.ReservedUsernames.Check(username) != nil {
+			suffix, err := crypto.RandomCode(4)
+			if err != nil {
+				return users.User{}, err
+			}
+			username = truncate(base, users.UsernameMaxLength-5) + "-" + strings.ToLower(suffix)
+		}
+
+		u := users.User{
+			Auth: users.Auth{
+				Username:  username,
+				Email:     iif.IfElse(claims.EmailVerified, claims.Email, ""),
+				Sessions:  []users.Session{},
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+			Identities: []users.Identity{identity},
+		}
+
+		u, err := m.userAdapter.NewUser(ctx, u)
+		if errors.Is(err, users.ErrUserNameAlreadyExists) {
+			continue
+		}
+		return u, err
+	}
+
+	return users.User{}, fmt.Errorf("create user: %w", users.ErrUserNameAlreadyExists)
+}
+
+// oidcUsername returns a valid username based on preferred_username or email claims
+func oidcUsername(claims oidc.Claims) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	var b strings.Builder
+	for _, r := range candidate {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || (b.Len() > 0 && strings.ContainsRune("._-", r)) {
+			b.WriteRune(r)
+		}
+	}
+
+	username, err := users.NormalizeUsername(truncate(b.String(), users.UsernameMaxLength))
+	if err != nil || users.ValidateUsername(username) != nil {
+		return "user"
+	}
+
+	return username
+}
+
+func truncate(s string, maxRunes int) string {
+	if runes := []rune(s); len(runes) > maxRunes {
+		return string(runes[:maxRunes])
+	}
+	return s
+}
+
+func oidcError(err error) *jsonerr.JSONError {
+	switch {
+	case errors.Is(err, ErrUnknownProvider):
+		return jsonerr.EchoNotFoundError(err)
+	case errors.Is(err, oidcstates.ErrInvalidState), errors.Is(err, ErrStateNotBound):
+		return jsonerr.EchoInvalidRequestError(err)
+	case errors.Is(err, users.ErrIdentityAlreadyLinked):
+		return jsonerr.EchoConflictError(err)
+	default:
+		return jsonerr.EchoInternalError(err)
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"whereiseveryone/internal/oidcstates"
+	"whereiseveryone/pkg/crypto"
+)
+
+func Test_CheckBinding(t *testing.T) {
+	state := oidcstates.State{BindingHash: crypto.HashToken("binding")}
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		want   error
+	}{
+		{"same client", &http.Cookie{Name: oidcBindingCookie, Value: "binding"}, nil},
+		{"no cookie", nil, ErrStateNotBound},
+		{"empty cookie", &http.Cookie{Name: oidcBindingCookie, Value: ""}, ErrStateNotBound},
+		{"another client", &http.Cookie{Name: oidcBindingCookie, Value: "other"}, ErrStateNotBound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/auth/oidc/test/callback", nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+
+			if err := checkBinding(echo.New().NewContext(req, rec), state); !errors.Is(err, tt.want) {
+				t.Errorf("checkBinding() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// legacy states without a binding are rejected as well
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/test/callback", nil)
+	req.AddCookie(&http.Cookie{Name: oidcBindingCookie, Value: "binding"})
+	err := checkBinding(echo.New().NewContext(req, httptest.NewRecorder()), oidcstates.State{})
+	if !errors.Is(err, ErrStateNotBound) {
+		t.Errorf("checkBinding() without binding = %v, want %v", err, ErrStateNotBound)
+	}
+}
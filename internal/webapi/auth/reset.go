@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"strings"
+	"whereiseveryone/internal/attempts"
+	"whereiseveryone/internal/resets"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/mail"
+)
+
+const resetCodeLength = 10
+
+// requestPasswordReset
+//
+// @summary request password reset
+// @description sends a single-use reset code to the user email.
+// @description The response is the same whether the user exists or not.
+// @description Requests are counted per account and per IP, after too many of them the reset is locked for some time.
+// @tags auth
+// @accept json
+// @param resetDetails body passwordResetRequest true "reset details"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 429 {object} jsonerr.JSONError "too many requests (see Retry-After header)"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/password-reset/request [POST]
+func (m *mux) requestPasswordReset(c echo.Context) error {
+	request, bindErr := binder.BindRequest[passwordResetRequest](c, false)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	// every request is counted (not only failed ones), each of them sends an email
+	accountKey := attempts.PasswordResetKey(attempts.AccountKey(users.UsernameKey(request.Request.Username)))
+	ipKey := attempts.PasswordResetKey(attempts.IPKey(c.RealIP()))
+	if _, _, attemptErr := m.beginAttempt(request.Context(), c, accountKey, ipKey); attemptErr != nil {
+		return attemptErr.Echo(c)
+	}
+
+	u, err := m.userAdapter.GetUserByUsername(request.Context(), request.Request.Username)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return c.NoContent(204) // don't reveal if the user exists
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if u.Auth.Email == "" {
+		c.Logger().Warnf("password reset requested for user without email: %s", u.ID.Hex())
+		return c.NoContent(204)
+	}
+
+	code, err := crypto.RandomCode(resetCodeLength)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	now := m.timer.Now()
+	err = m.resets.Create(request.Context(), resets.Reset{
+		UserID:    u.ID,
+		CodeHash:  crypto.HashToken(code),
+		Attempts:  0,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.config.ResetValidity),
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	err = m.sender.Send(request.Context(), mail.Message{
+		To:      u.Auth.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nyour password reset code is: %s\nThe code expires in %s.\n\n"+
+				"If you didn't request the reset, just ignore this message.\n",
+			u.Auth.Username, code, m.config.ResetValidity),
+	})
+	if err != nil {
+		// don't reveal if the user exists, the user can request the code again
+		c.Logger().Errorf("send password reset code: %v", err)
+	}
+
+	return c.NoContent(204)
+}
+
+// confirmPasswordReset
+//
+// @summary confirm password reset
+// @description sets a new password using the reset code, all user sessions are logged out
+// @tags auth
+// @accept json
+// @param resetDetails body passwordResetConfirmRequest true "reset confirmation"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 403 {object} jsonerr.JSONError "invalid or expired code"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/password-reset/confirm [POST]
+func (m *mux) confirmPasswordReset(c echo.Context) error {
+	request, bindErr := binder.BindRequest[passwordResetConfirmRequest](c, false)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	u, err := m.userAdapter.GetUserByUsername(request.Context(), request.Request.Username)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoError(403, "forbidden", resets.ErrInvalidCode).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(request.Request.Code))
+	if err := m.resets.Consume(request.Context(), u.ID, crypto.HashToken(code)); err != nil {
+		if errors.Is(err, resets.ErrInvalidCode) {
+			return jsonerr.EchoError(403, "forbidden", err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	encPass, err := m.hasher.Hash(request.Request.NewPassword)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if err := m.userAdapter.UpdatePassword(request.Context(), u.ID, encPass); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	if err := m.revokeAll(request.Context(), u.ID); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"time"
+	"whereiseveryone/internal/attempts"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+)
+
+var ErrInvalidChallenge = errors.New("invalid or expired challenge token")
+
+// logInTwoFactor
+//
+// @summary log in with the second factor
+// @description exchanges the challenge token returned by login and TOTP code (or one of recovery codes) for tokens.
+// @description Each challenge token and code can be used only once, failed attempts are counted like log in attempts.
+// @tags auth
+// @accept json
+// @produces json
+// @param twoFactorDetails body twoFactorLogInRequest true "challenge token and code"
+// @success 200 {object} authResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 401 {object} jsonerr.JSONError "invalid challenge token or code"
+// @failure 429 {object} jsonerr.JSONError "too many failed attempts (see Retry-After header)"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /auth/2fa [POST]
+func (m *mux) logInTwoFactor(c echo.Context) error {
+	reqCtx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(60)*time.Second)
+	defer cancel()
+
+	var request twoFactorLogInRequest
+	if err := c.Bind(&request); err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+	if err := c.Validate(request); err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	claims, err := m.jwt.ValidateChallengeToken(request.ChallengeToken)
+	if err != nil {
+		return jsonerr.EchoUnauthorizedError(ErrInvalidChallenge).Echo(c)
+	}
+	revoked, err := m.revokedTokens.IsRevoked(reqCtx, claims)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if revoked {
+		return jsonerr.EchoUnauthorizedError(ErrInvalidChallenge).Echo(c)
+	}
+
+	userID, err := id.FromString(claims.Subject)
+	if err != nil || userID == id.ZeroID {
+		return jsonerr.EchoUnauthorizedError(ErrInvalidChallenge).Echo(c)
+	}
+
+	u, err := m.userAdapter.GetUser(reqCtx, userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoUnauthorizedError(ErrInvalidChallenge).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	accountKey, ipKey := attempts.AccountKey(users.UsernameKey(u.Auth.Username)), attempts.IPKey(c.RealIP())
+	// counted as failed before the code is verified like in logIn
+	_, ip, attemptErr := m.beginAttempt(reqCtx, c, accountKey, ipKey)
+	if attemptErr != nil {
+		return attemptErr.Echo(c)
+	}
+
+	err = users.VerifySecondFactor(reqCtx, m.userAdapter, m.otp, m.timer.Now(), u, request.Code)
+	if err != nil {
+		if !errors.Is(err, users.ErrInvalidSecondFactor) && !errors.Is(err, users.ErrTwoFactorNotEnabled) {
+			return jsonerr.EchoInternalError(err).Echo(c)
+		}
+		return jsonerr.EchoUnauthorizedError(users.ErrInvalidSecondFactor).Echo(c)
+	}
+
+	if err := m.attempts.Release(reqCtx, ip); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if err := m.attempts.Reset(reqCtx, accountKey); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	// the challenge is single use
+	if err := m.revokedTokens.RevokeToken(reqCtx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	response, err := m.newSession(reqCtx, c, u, request.DeviceName, requestedScopes(claims.Scopes))
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(200, response)
+}
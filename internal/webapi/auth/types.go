@@ -1,10 +1,14 @@
 package auth
 
 type signUpRequest struct {
-	// Username username, must be unique
-	Username string `json:"username" validate:"required"`
+	// Username username, must be unique (case-insensitive), 3-32 letters (of a single script), digits, `.`, `_` or `-`
+	Username string `json:"username" validate:"required,min=3,max=32,username"`
 	// Password user password, min 8 characters
 	Password string `json:"password" validate:"required,min=8"`
+	// Email optional email, required for password reset
+	Email string `json:"email" validate:"omitempty,email"`
+	// DeviceName optional name of the device, used to identify the session
+	DeviceName string `json:"device_name" validate:"max=64"`
 }
 
 type logInRequest struct {
@@ -12,13 +16,81 @@ type logInRequest struct {
 	Username string `json:"username" validate:"required"`
 	// Password user password
 	Password string `json:"password" validate:"required"`
+	// DeviceName optional name of the device, used to identify the session
+	DeviceName string `json:"device_name" validate:"max=64"`
+	// Scopes optional scopes of issued tokens (location:write, friends:read, friends:write, profile:read, profile:write),
+	// all scopes are granted if empty
+	Scopes []string `json:"scopes" validate:"omitempty,dive,scope"`
+}
+
+type challengeResponse struct {
+	// ChallengeToken is a short-lived token to be exchanged for tokens with the second factor
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type twoFactorLogInRequest struct {
+	// ChallengeToken returned by login
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is TOTP code or one of recovery codes
+	Code string `json:"code" validate:"required"`
+	// DeviceName optional name of the device, used to identify the session
+	DeviceName string `json:"device_name" validate:"max=64"`
+}
+
+type oidcLogInRequest struct {
+	// Provider is a name of OIDC provider
+	Provider string `param:"provider" validate:"required"`
+	// DeviceName optional name of the device, used to identify the session
+	DeviceName string `query:"device_name" validate:"max=64"`
+}
+
+type oidcLinkRequest struct {
+	// Provider is a name of OIDC provider
+	Provider string `param:"provider" validate:"required"`
+}
+
+type oidcLinkResponse struct {
+	// URL of the provider authorization page the user should open
+	URL string `json:"url"`
+}
+
+type oidcCallbackRequest struct {
+	// Provider is a name of OIDC provider
+	Provider string `param:"provider" validate:"required"`
+	// Code is authorization code
+	Code string `query:"code"`
+	// State is the state sent to the provider
+	State string `query:"state" validate:"required"`
+	// Error is set by the provider if the authorization failed
+	Error string `query:"error"`
+}
+
+type refreshRequest struct {
+	// RefreshToken refresh token returned by signup, login or previous refresh
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 type authResponse struct {
 	// ID is user id (uuid)
 	ID string `json:"id"`
+	// SessionID is an ID of the created (or refreshed) session
+	SessionID string `json:"session_id"`
 	// Token user auth token (Bearer)
 	Token string `json:"token"`
 	// RefreshToken user refresh token
 	RefreshToken string `json:"refresh_token"`
 }
+
+type passwordResetRequest struct {
+	// Username of the user who forgot the password
+	Username string `json:"username" validate:"required"`
+}
+
+type passwordResetConfirmRequest struct {
+	// Username of the user who forgot the password
+	Username string `json:"username" validate:"required"`
+	// Code sent to the user
+	Code string `json:"code" validate:"required"`
+	// NewPassword new user password, min 8 characters
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
@@ -19,6 +19,7 @@ type BaseContext interface {
 	Echo() echo.Context
 	UserID() id.ID
 	TokenData() jwt.SignedToken
+	Scopes() []string
 }
 
 type EmptyBody struct {
@@ -56,6 +57,11 @@ func (c Context[T]) TokenData() jwt.SignedToken {
 	return c.tokenData
 }
 
+// Scopes returns scopes of the token used for the request
+func (c Context[T]) Scopes() []string {
+	return c.tokenData.Scopes
+}
+
 type StructValidator interface {
 	Struct(str any) error
 }
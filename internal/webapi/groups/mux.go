@@ -0,0 +1,713 @@
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/groups"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/timer"
+)
+
+var (
+	ErrOwnerCannotLeave    = errors.New("owner cannot leave the group, transfer the ownership or delete the group")
+	ErrInsufficientRole    = errors.New("insufficient group role")
+	ErrCannotChangeOwnRole = errors.New("cannot change own role, transfer the ownership instead")
+
+	errInvalidGroupID = errors.New("invalid group id")
+)
+
+type mux struct {
+	groups      groups.Adapter
+	userAdapter users.Adapter
+	timer       timer.Timer
+}
+
+func NewMux(groups groups.Adapter, userAdapter users.Adapter, timer timer.Timer) *mux {
+	return &mux{
+		groups:      groups,
+		userAdapter: userAdapter,
+		timer:       timer,
+	}
+}
+
+func (m *mux) Route(g *echo.Group, _ echo.MiddlewareFunc) {
+	friendsRead := webapi.RequireScopes(webapi.ScopeFriendsRead)
+	friendsWrite := webapi.RequireScopes(webapi.ScopeFriendsWrite)
+
+	g.POST("", m.createGroup, friendsWrite)
+	g.GET("", m.getGroups, friendsRead)
+	g.GET("/invitations", m.getInvitations, friendsRead)
+	g.GET("/:id", m.getGroup, friendsRead)
+	g.DELETE("/:id", m.deleteGroup, friendsWrite)
+	g.POST("/:id/invites", m.invite, friendsWrite)
+	g.POST("/:id/join", m.join, friendsWrite)
+	g.POST("/:id/decline", m.decline, friendsWrite)
+	g.POST("/:id/leave", m.leave, friendsWrite)
+	g.GET("/:id/members", m.getMembers, friendsRead)
+	g.DELETE("/:id/members/:username", m.removeMember, friendsWrite)
+	g.PUT("/:id/members/:username/role", m.setRole, friendsWrite)
+	g.PUT("/:id/precision", m.setPrecision, friendsWrite)
+}
+
+// createGroup
+//
+// @summary create group
+// @description creates a new group, the requester becomes its owner
+// @tags groups
+// @accept json
+// @produce json
+// @param group body createGroupRequest true "group to create"
+// @success 201 {object} groupDetails
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups [POST]
+func (m *mux) createGroup(c echo.Context) error {
+	request, bindErr := binder.BindRequest[createGroupRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	now := m.timer.Now()
+	group, err := m.groups.Create(request.Context(), groups.Group{
+		Name: request.Request.Name,
+		Members: []groups.Member{{
+			UserID:   request.UserID(),
+			Role:     groups.RoleOwner,
+			JoinedAt: now,
+		}},
+		CreatedAt: now,
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result, err := m.toGroupDetails(request.Context(), group, groups.RoleOwner)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// getGroups
+//
+// @summary get groups
+// @description returns groups the requester is a member of
+// @tags groups
+// @produce json
+// @success 200 {object} getGroupsResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups [GET]
+func (m *mux) getGroups(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	userGroups, err := m.groups.GetUserGroups(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result := make(getGroupsResponse, 0, len(userGroups))
+	for _, g := range userGroups {
+		member, _ := g.Member(request.UserID())
+		result = append(result, groupSummary{
+			ID:           g.ID.Hex(),
+			Name:         g.Name,
+			Role:         string(member.Role),
+			MembersCount: len(g.Members),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// getInvitations
+//
+// @summary get group invitations
+// @description returns groups the requester is invited to
+// @tags groups
+// @produce json
+// @success 200 {object} getInvitationsResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/invitations [GET]
+func (m *mux) getInvitations(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	invitations, err := m.groups.GetInvitations(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	inviterIDs := make([]id.ID, 0, len(invitations))
+	for _, g := range invitations {
+		invite, _ := g.Invite(request.UserID())
+		inviterIDs = append(inviterIDs, invite.InvitedBy)
+	}
+	usernames, err := m.usernames(request.Context(), inviterIDs)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result := make(getInvitationsResponse, 0, len(invitations))
+	for _, g := range invitations {
+		invite, _ := g.Invite(request.UserID())
+		result = append(result, invitationDetails{
+			ID:        g.ID.Hex(),
+			Name:      g.Name,
+			InvitedBy: usernames[invite.InvitedBy],
+			CreatedAt: invite.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// getGroup
+//
+// @summary get group
+// @description returns the group with its members and roles, pending invitations are returned to admins only
+// @tags groups
+// @produce json
+// @param id path string true "group id"
+// @success 200 {object} groupDetails
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "group not exists or the requester is not a member"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id} [GET]
+func (m *mux) getGroup(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, member, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	result, err := m.toGroupDetails(request.Context(), group, member.Role)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// deleteGroup
+//
+// @summary delete group
+// @description removes the group, only the owner can delete it
+// @tags groups
+// @param id path string true "group id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 403 {object} jsonerr.JSONError "the requester is not the owner"
+// @failure 404 {object} jsonerr.JSONError "group not exists or the requester is not a member"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id} [DELETE]
+func (m *mux) deleteGroup(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, member, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+	if member.Role != groups.RoleOwner {
+		return groupError(ErrInsufficientRole).Echo(c)
+	}
+
+	if err := m.groups.Delete(request.Context(), group.ID); err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// invite
+//
+// @summary invite to group
+// @description invites the user to the group, only owner and admins can invite
+// @tags groups
+// @accept json
+// @param id path string true "group id"
+// @param user body inviteRequest true "user to invite"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 403 {object} jsonerr.JSONError "the requester is not an admin"
+// @failure 404 {object} jsonerr.JSONError "group or user not exists"
+// @failure 409 {object} jsonerr.JSONError "the user is already a member or invited"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/invites [POST]
+func (m *mux) invite(c echo.Context) error {
+	request, bindErr := binder.BindRequest[inviteRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, member, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+	if !member.Role.CanManage(groups.RoleMember) {
+		return groupError(ErrInsufficientRole).Echo(c)
+	}
+
+	invited, err := m.userAdapter.GetUserByUsername(request.Context(), request.Request.Username)
+	if err == nil && invited.Blocks(request.UserID()) {
+		err = users.ErrUserNotExists // the blocker is not found for the blocked user
+	}
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	err = m.groups.Invite(request.Context(), group.ID, groups.Invite{
+		UserID:    invited.ID,
+		InvitedBy: request.UserID(),
+		CreatedAt: m.timer.Now(),
+	})
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// join
+//
+// @summary join group
+// @description accepts the invitation, the requester becomes a member and shares the location with other members
+// @tags groups
+// @param id path string true "group id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "the requester is not invited"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/join [POST]
+func (m *mux) join(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	groupID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if err := m.groups.Join(request.Context(), groupID, request.UserID()); err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// decline
+//
+// @summary decline group invitation
+// @description removes the invitation
+// @tags groups
+// @param id path string true "group id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "the requester is not invited"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/decline [POST]
+func (m *mux) decline(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	groupID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if err := m.groups.DeclineInvite(request.Context(), groupID, request.UserID()); err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// leave
+//
+// @summary leave group
+// @description removes the requester from the group, the owner cannot leave
+// @tags groups
+// @param id path string true "group id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "group not exists or the requester is not a member"
+// @failure 409 {object} jsonerr.JSONError "the requester is the owner"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/leave [POST]
+func (m *mux) leave(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, member, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+	if member.Role == groups.RoleOwner {
+		return groupError(ErrOwnerCannotLeave).Echo(c)
+	}
+
+	if err := m.groups.RemoveMember(request.Context(), group.ID, request.UserID()); err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// getMembers
+//
+// @summary get group members details
+// @description returns details of other group members (membership is a consent to share the location),
+// @description the location has the precision set by the member for the requester (see /me/precision),
+// @description otherwise friends of the member get the exact location and others the member's group precision
+// @description (see /groups/{id}/precision). Ghost mode is respected.
+// @tags groups
+// @produce json
+// @param id path string true "group id"
+// @success 200 {object} getMembersResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "group not exists or the requester is not a member"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/members [GET]
+func (m *mux) getMembers(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, _, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	memberIDs := make([]id.ID, 0, len(group.Members))
+	for _, member := range group.Members {
+		if member.UserID != request.UserID() {
+			memberIDs = append(memberIDs, member.UserID)
+		}
+	}
+
+	members, err := m.userAdapter.GetUsers(request.Context(), memberIDs)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	requester, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	now := m.timer.Now()
+	result := make(getMembersResponse, 0, len(members))
+	for _, u := range members {
+		if u.Blocks(request.UserID()) {
+			continue
+		}
+
+		member, _ := group.Member(u.ID)
+		precision := memberPrecision(requester, u, member)
+		details := memberDetails{
+			Username:  u.Auth.Username,
+			Status:    u.Status,
+			Precision: string(precision),
+			Hidden:    u.LocationHidden(now),
+		}
+		if l := u.LocationWithPrecision(precision, now); l != nil {
+			details.Location = &locationDetails{
+				Longitude:  l.Longitude,
+				Latitude:   l.Latitude,
+				Altitude:   l.Altitude,
+				Bearing:    l.Bearing,
+				Accuracy:   l.Accuracy,
+				LastUpdate: l.LastUpdate,
+			}
+		}
+		result = append(result, details)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// removeMember
+//
+// @summary remove group member
+// @description removes the member, the owner can remove anyone, admins can remove members only
+// @tags groups
+// @param id path string true "group id"
+// @param username path string true "member username"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 403 {object} jsonerr.JSONError "insufficient role"
+// @failure 404 {object} jsonerr.JSONError "group or member not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/members/{username} [DELETE]
+func (m *mux) removeMember(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, requester, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	target, err := m.groupMember(request.Context(), group, c.Param("username"))
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+	if !requester.Role.CanManage(target.Role) {
+		return groupError(ErrInsufficientRole).Echo(c)
+	}
+
+	if err := m.groups.RemoveMember(request.Context(), group.ID, target.UserID); err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// setRole
+//
+// @summary set group member role
+// @description changes the member role, only the owner can do it.
+// @description Setting owner role transfers the ownership, the previous owner becomes an admin.
+// @tags groups
+// @accept json
+// @param id path string true "group id"
+// @param username path string true "member username"
+// @param role body setRoleRequest true "new role"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 403 {object} jsonerr.JSONError "the requester is not the owner"
+// @failure 404 {object} jsonerr.JSONError "group or member not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/members/{username}/role [PUT]
+func (m *mux) setRole(c echo.Context) error {
+	request, bindErr := binder.BindRequest[setRoleRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, requester, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+	if requester.Role != groups.RoleOwner {
+		return groupError(ErrInsufficientRole).Echo(c)
+	}
+
+	target, err := m.groupMember(request.Context(), group, c.Param("username"))
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+	if target.UserID == request.UserID() {
+		return jsonerr.EchoInvalidRequestError(ErrCannotChangeOwnRole).Echo(c)
+	}
+
+	role := groups.Role(request.Request.Role)
+	if role == groups.RoleOwner {
+		err = m.groups.TransferOwnership(request.Context(), group.ID, request.UserID(), target.UserID)
+	} else {
+		err = m.groups.SetRole(request.Context(), group.ID, target.UserID, role)
+	}
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// setPrecision
+//
+// @summary set group location precision
+// @description sets the precision of the requester location shared with group members who are not requester's friends
+// @description (exact by default), the precision set for a member with /me/precision takes priority
+// @tags groups
+// @accept json
+// @param id path string true "group id"
+// @param precision body setGroupPrecisionRequest true "precision"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "group not exists or the requester is not a member"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /groups/{id}/precision [PUT]
+func (m *mux) setPrecision(c echo.Context) error {
+	request, bindErr := binder.BindRequest[setGroupPrecisionRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	group, _, err := m.memberGroup(request.Context(), c.Param("id"), request.UserID())
+	if err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	precision := users.Precision(request.Request.Precision)
+	if err := m.groups.SetPrecision(request.Context(), group.ID, request.UserID(), precision); err != nil {
+		return groupError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// memberGroup returns the group and the user membership, non-members get ErrGroupNotExists
+// so they can't tell if the group exists
+func (m *mux) memberGroup(ctx context.Context, rawGroupID string, userID id.ID) (groups.Group, groups.Member, error) {
+	groupID, err := id.FromString(rawGroupID)
+	if err != nil {
+		return groups.Group{}, groups.Member{}, fmt.Errorf("%w: %w", errInvalidGroupID, err)
+	}
+
+	group, err := m.groups.Get(ctx, groupID)
+	if err != nil {
+		return groups.Group{}, groups.Member{}, err
+	}
+
+	member, ok := group.Member(userID)
+	if !ok {
+		return groups.Group{}, groups.Member{}, groups.ErrGroupNotExists
+	}
+
+	return group, member, nil
+}
+
+// groupMember returns the group member by username, ErrMemberNotExists if the user is not a member
+func (m *mux) groupMember(ctx context.Context, group groups.Group, username string) (groups.Member, error) {
+	u, err := m.userAdapter.GetUserByUsername(ctx, username)
+	if errors.Is(err, users.ErrUserNotExists) {
+		return groups.Member{}, groups.ErrMemberNotExists
+	}
+	if err != nil {
+		return groups.Member{}, err
+	}
+
+	member, ok := group.Member(u.ID)
+	if !ok {
+		return groups.Member{}, groups.ErrMemberNotExists
+	}
+
+	return member, nil
+}
+
+// memberPrecision returns the precision of the member location shared with the requester: the one set
+// for the requester, otherwise exact for friends (like /me/friends) and the member's group precision for others
+func memberPrecision(requester, u users.User, member groups.Member) users.Precision {
+	if u.SubscribeUser(requester.ID) && requester.SubscribeUser(u.ID) {
+		return u.PrecisionFor(requester.ID)
+	}
+
+	return u.PrecisionOr(requester.ID, member.LocationPrecision())
+}
+
+func (m *mux) toGroupDetails(ctx context.Context, group groups.Group, role groups.Role) (groupDetails, error) {
+	userIDs := make([]id.ID, 0, len(group.Members)+len(group.Invites))
+	for _, member := range group.Members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	for _, invite := range group.Invites {
+		userIDs = append(userIDs, invite.UserID)
+	}
+
+	usernames, err := m.usernames(ctx, userIDs)
+	if err != nil {
+		return groupDetails{}, err
+	}
+
+	result := groupDetails{
+		ID:        group.ID.Hex(),
+		Name:      group.Name,
+		CreatedAt: group.CreatedAt,
+		Members:   make([]groupMemberDetails, 0, len(group.Members)),
+	}
+	for _, member := range group.Members {
+		result.Members = append(result.Members, groupMemberDetails{
+			Username: usernames[member.UserID],
+			Role:     string(member.Role),
+			JoinedAt: member.JoinedAt,
+		})
+	}
+	if role.CanManage(groups.RoleMember) {
+		invites := make([]groupInviteDetails, 0, len(group.Invites))
+		for _, invite := range group.Invites {
+			invites = append(invites, groupInviteDetails{
+				Username:  usernames[invite.UserID],
+				InvitedBy: usernames[invite.InvitedBy],
+				CreatedAt: invite.CreatedAt,
+			})
+		}
+		result.Invites = invites
+	}
+
+	return result, nil
+}
+
+// usernames returns usernames of the users by their IDs
+func (m *mux) usernames(ctx context.Context, userIDs []id.ID) (map[id.ID]string, error) {
+	us, err := m.userAdapter.GetUsers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[id.ID]string, len(us))
+	for _, u := range us {
+		result[u.ID] = u.Auth.Username
+	}
+
+	return result, nil
+}
+
+func groupError(err error) *jsonerr.JSONError {
+	switch {
+	case errors.Is(err, errInvalidGroupID):
+		return jsonerr.EchoInvalidRequestError(err)
+	case errors.Is(err, ErrInsufficientRole):
+		return jsonerr.EchoError(http.StatusForbidden, "forbidden", err)
+	case errors.Is(err, groups.ErrGroupNotExists),
+		errors.Is(err, groups.ErrMemberNotExists),
+		errors.Is(err, groups.ErrNotInvited),
+		errors.Is(err, users.ErrUserNotExists):
+		return jsonerr.EchoNotFoundError(err)
+	case errors.Is(err, groups.ErrAlreadyMember), errors.Is(err, ErrOwnerCannotLeave):
+		return jsonerr.EchoConflictError(err)
+	default:
+		return jsonerr.EchoInternalError(err)
+	}
+}
@@ -0,0 +1,136 @@
+package groups
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"whereiseveryone/internal/groups"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/internal/webapitest"
+	"whereiseveryone/pkg/id"
+)
+
+func Test_MemberRoles(t *testing.T) {
+	owner, admin, member, other := id.NewID(), id.NewID(), id.NewID(), id.NewID()
+	group := &groups.Group{
+		ID: id.NewID(),
+		Members: []groups.Member{
+			{UserID: owner, Role: groups.RoleOwner},
+			{UserID: admin, Role: groups.RoleAdmin},
+			{UserID: member, Role: groups.RoleMember},
+			{UserID: other, Role: groups.RoleMember},
+		},
+	}
+	usersAdapter := webapitest.NewUsers(
+		&users.User{ID: owner, Auth: users.Auth{Username: "owner"}},
+		&users.User{ID: admin, Auth: users.Auth{Username: "admin"}},
+		&users.User{ID: member, Auth: users.Auth{Username: "member"}},
+		&users.User{ID: other, Auth: users.Auth{Username: "other"}},
+	)
+
+	e := webapitest.NewEcho()
+	tm := &webapitest.Timer{Time: time.Now()}
+	NewMux(webapitest.NewGroups(group), usersAdapter, tm).Route(e.Group("/groups", webapitest.Auth), webapitest.Auth)
+
+	do := func(requester id.ID, method, path, body string) int {
+		return webapitest.Request(e, method, "/groups/"+group.ID.Hex()+path, body, requester).Code
+	}
+	role := func(userID id.ID) groups.Role {
+		m, _ := group.Member(userID)
+		return m.Role
+	}
+
+	steps := []struct {
+		name      string
+		requester id.ID
+		method    string
+		path      string
+		body      string
+		want      int
+	}{
+		{"admin cannot set roles", admin, http.MethodPut, "/members/member/role", `{"role":"admin"}`, 403},
+		{"admin cannot remove admin", admin, http.MethodDelete, "/members/admin", "", 403},
+		{"admin cannot remove owner", admin, http.MethodDelete, "/members/owner", "", 403},
+		{"member cannot remove member", member, http.MethodDelete, "/members/other", "", 403},
+		{"owner cannot change own role", owner, http.MethodPut, "/members/owner/role", `{"role":"admin"}`, 400},
+		{"owner promotes member", owner, http.MethodPut, "/members/member/role", `{"role":"admin"}`, 204},
+		{"admin removes member", admin, http.MethodDelete, "/members/other", "", 204},
+		{"owner transfers ownership", owner, http.MethodPut, "/members/admin/role", `{"role":"owner"}`, 204},
+		{"previous owner cannot set roles", owner, http.MethodPut, "/members/member/role", `{"role":"member"}`, 403},
+		{"new owner removes previous owner", admin, http.MethodDelete, "/members/owner", "", 204},
+	}
+
+	for _, s := range steps {
+		if got := do(s.requester, s.method, s.path, s.body); got != s.want {
+			t.Fatalf("%s: status %d, want %d", s.name, got, s.want)
+		}
+	}
+
+	if role(admin) != groups.RoleOwner || role(member) != groups.RoleAdmin {
+		t.Fatalf("unexpected roles, admin: %s, member: %s", role(admin), role(member))
+	}
+	if _, ok := group.Member(owner); ok {
+		t.Fatalf("previous owner should be removed")
+	}
+}
+
+func Test_GetMembers_Precision(t *testing.T) {
+	alice, bob, carol, dave, erin := id.NewID(), id.NewID(), id.NewID(), id.NewID(), id.NewID()
+	group := &groups.Group{
+		ID: id.NewID(),
+		Members: []groups.Member{
+			{UserID: alice, Role: groups.RoleOwner},
+			{UserID: bob, Role: groups.RoleMember, Precision: users.Precision10km},
+			{UserID: carol, Role: groups.RoleMember},
+			{UserID: dave, Role: groups.RoleMember, Precision: users.Precision10km},
+			{UserID: erin, Role: groups.RoleMember},
+		},
+	}
+	usersAdapter := webapitest.NewUsers(
+		&users.User{ID: alice, Auth: users.Auth{Username: "alice"}, SubscribedUsers: []id.ID{bob}},
+		// friend of alice
+		&users.User{ID: bob, Auth: users.Auth{Username: "bob"}, SubscribedUsers: []id.ID{alice}},
+		&users.User{ID: carol, Auth: users.Auth{Username: "carol"}},
+		&users.User{
+			ID:                dave,
+			Auth:              users.Auth{Username: "dave"},
+			LocationPrecision: map[string]users.Precision{alice.Hex(): users.Precision1km},
+		},
+		&users.User{ID: erin, Auth: users.Auth{Username: "erin"}},
+	)
+
+	e := webapitest.NewEcho()
+	tm := &webapitest.Timer{Time: time.Now()}
+	NewMux(webapitest.NewGroups(group), usersAdapter, tm).Route(e.Group("/groups", webapitest.Auth), webapitest.Auth)
+
+	path := "/groups/" + group.ID.Hex()
+	if rec := webapitest.Request(e, http.MethodPut, path+"/precision", `{"precision":"status_only"}`, carol); rec.Code != 204 {
+		t.Fatalf("set group precision: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := webapitest.Request(e, http.MethodGet, path+"/members", "", alice)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get members: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var members getMembersResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &members); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	want := map[string]string{
+		"bob":   "exact",       // friends get the precision set for them (exact by default)
+		"carol": "status_only", // others get the group precision
+		"dave":  "1km",         // unless the precision is set for the requester
+		"erin":  "exact",
+	}
+	if len(members) != len(want) {
+		t.Fatalf("got %d members, want %d", len(members), len(want))
+	}
+	for _, m := range members {
+		if m.Precision != want[m.Username] {
+			t.Errorf("%s: precision %q, want %q", m.Username, m.Precision, want[m.Username])
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package groups
+
+import "time"
+
+type createGroupRequest struct {
+	// Name of the group, e.g. "family"
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type getGroupsResponse []groupSummary
+
+type groupSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Role of the requester in the group
+	Role         string `json:"role" enums:"owner,admin,member"`
+	MembersCount int    `json:"members_count"`
+}
+
+type groupDetails struct {
+	ID      string               `json:"id"`
+	Name    string               `json:"name"`
+	Members []groupMemberDetails `json:"members"`
+	// Invites are pending invitations, returned to the owner and admins only
+	Invites []groupInviteDetails `json:"invites,omitempty"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type groupMemberDetails struct {
+	Username string `json:"username"`
+	Role     string `json:"role" enums:"owner,admin,member"`
+	// JoinedAt in UTC time
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type groupInviteDetails struct {
+	Username string `json:"username"`
+	// InvitedBy is a username of the member who sent the invitation
+	InvitedBy string `json:"invited_by"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type getInvitationsResponse []invitationDetails
+
+type invitationDetails struct {
+	// ID is a group id
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// InvitedBy is a username of the member who sent the invitation
+	InvitedBy string `json:"invited_by"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type inviteRequest struct {
+	// Username of the user to invite
+	Username string `json:"username" validate:"required"`
+}
+
+type setRoleRequest struct {
+	// Role is a new member role, owner transfers the ownership
+	Role string `json:"role" validate:"required,oneof=owner admin member" enums:"owner,admin,member"`
+}
+
+type setGroupPrecisionRequest struct {
+	// Precision of the location shared with group members who are not friends
+	Precision string `json:"precision" validate:"required,oneof=exact 1km 10km status_only"`
+}
+
+// getMembersResponse has the same shape as friends details (/me/friends)
+type getMembersResponse []memberDetails
+
+type memberDetails struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	// Location is null if the user has no location or shares the status only
+	Location *locationDetails `json:"location"`
+	// Precision of the shared location
+	Precision string `json:"precision" enums:"exact,1km,10km,status_only"`
+	// Hidden is true if the user hides the location from everyone (ghost mode)
+	Hidden bool `json:"location_hidden"`
+}
+
+type locationDetails struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+	Altitude  float64 `json:"altitude,omitempty"`
+	Bearing   float64 `json:"bearing,omitempty"`
+	Accuracy  float64 `json:"accuracy,omitempty"`
+	// LastUpdate in UTC time
+	LastUpdate time.Time `json:"last_update"`
+}
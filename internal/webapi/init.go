@@ -4,14 +4,28 @@ import (
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4/middleware"
+	"slices"
 	"strings"
+	"whereiseveryone/internal/apikeys"
+	"whereiseveryone/internal/tokens"
+	"whereiseveryone/internal/users"
 
 	"github.com/go-playground/validator"
+	jwtgo "github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
+	"whereiseveryone/pkg/crypto"
 	"whereiseveryone/pkg/jwt"
 	"whereiseveryone/pkg/logger"
 )
 
+// APIKeyHeader is a header with device API key, accepted instead of bearer token on apiKeyRoutes only
+const APIKeyHeader = "X-Api-Key"
+
+// apiKeyRoutes are routes (method and path without base path) which can be accessed with API keys
+var apiKeyRoutes = map[string]struct{}{ //nolint:gochecknoglobals // cannot be const
+	"PUT /me/location": {},
+}
+
 type Router interface {
 	Route(g *echo.Group, authMiddleware echo.MiddlewareFunc)
 }
@@ -34,27 +48,90 @@ func GetJWTToken(c echo.Context) (jwt.SignedToken, error) {
 	return jwtToken, nil
 }
 
+// RegisterValidations registers app specific validation tags
+func RegisterValidations(validate *validator.Validate) error {
+	err := validate.RegisterValidation("scope", func(fl validator.FieldLevel) bool {
+		return slices.Contains(AllScopes, fl.Field().String())
+	})
+	if err != nil {
+		return fmt.Errorf("register scope validation: %w", err)
+	}
+
+	// the policy is checked on the normalized username, the one which is stored
+	err = validate.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		username, err := users.NormalizeUsername(fl.Field().String())
+		return err == nil && users.ValidateUsername(username) == nil
+	})
+	if err != nil {
+		return fmt.Errorf("register username validation: %w", err)
+	}
+
+	return nil
+}
+
+// apiKeyAuth authenticates the request with device API key, the request gets a token without session
+// (API keys are revoked by removing them, not by revoking tokens).
+func apiKeyAuth(c echo.Context, next echo.HandlerFunc, basePath string, apiKeys apikeys.Adapter, apiKey string) error {
+	route := c.Request().Method + " " + strings.TrimPrefix(c.Path(), basePath)
+	if _, ok := apiKeyRoutes[route]; !ok {
+		return c.String(403, "api keys can be used only to update the location")
+	}
+
+	key, err := apiKeys.GetByHash(c.Request().Context(), crypto.HashToken(apiKey))
+	if errors.Is(err, apikeys.ErrKeyNotExists) {
+		return c.String(401, "invalid api key")
+	}
+	if err != nil {
+		return c.String(500, fmt.Sprintf("check api key: %s", err.Error()))
+	}
+
+	c.Set("user", jwt.SignedToken{
+		ID:     key.UserID.Hex(),
+		Type:   jwt.TokenTypeAccess,
+		Scopes: []string{ScopeLocationWrite},
+		StandardClaims: jwtgo.StandardClaims{
+			Id:      key.ID.Hex(),
+			Subject: key.UserID.Hex(),
+		},
+	})
+
+	return next(c)
+}
+
 type EchoRouters struct {
-	Swagger    echo.HandlerFunc
-	AuthRouter Router
-	MeRouter   Router
+	Swagger      echo.HandlerFunc
+	AuthRouter   Router
+	MeRouter     Router
+	GroupsRouter Router
+	PublicRouter Router
 }
 
 func NewEcho(
 	basePath string,
 	validate *validator.Validate,
 	jwtInstance *jwt.JWT,
+	revokedTokens tokens.Adapter,
+	apiKeys apikeys.Adapter,
 	routers EchoRouters,
+	ipExtractor echo.IPExtractor,
 	log logger.Logger,
 	debug bool,
 ) *echo.Echo {
 	e := echo.New()
 	e.Debug = debug
+	// client IP is used by log in protection and stored in sessions, it must not be taken from spoofable headers
+	e.IPExtractor = ipExtractor
+	if err := RegisterValidations(validate); err != nil {
+		log.Fatalf("register validations: %s", err.Error())
+	}
 	e.Validator = &echoValidator{validator: validate}
 
 	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			jwtToken := c.Request().Header.Get("Authorization")
+			if apiKey := c.Request().Header.Get(APIKeyHeader); jwtToken == "" && apiKey != "" {
+				return apiKeyAuth(c, next, basePath, apiKeys, apiKey)
+			}
 			if jwtToken == "" {
 				return c.String(403, "missing jwt token")
 			}
@@ -64,9 +141,24 @@ func NewEcho(
 			}
 
 			v, err := jwtInstance.ValidateToken(strings.TrimPrefix(jwtToken, "Bearer "))
+			if errors.Is(err, jwt.ErrInvalidTokenType) {
+				return c.String(403, "invalid token: only access tokens can be used as bearer token")
+			}
 			if err != nil {
 				return c.String(403, fmt.Sprintf("invalid token: %s", err.Error()))
 			}
+
+			revoked, err := revokedTokens.IsRevoked(c.Request().Context(), v)
+			if err != nil {
+				return c.String(500, fmt.Sprintf("check token: %s", err.Error()))
+			}
+			if revoked {
+				return c.String(401, "token has been revoked")
+			}
+			if v.Scopes == nil {
+				// tokens issued before scopes were introduced, valid until they expire
+				v.Scopes = AllScopes
+			}
 			c.Set("user", v)
 
 			return next(c)
@@ -76,11 +168,18 @@ func NewEcho(
 	basePathGroup := e.Group(basePath)
 
 	e.GET("/swagger/*", routers.Swagger)
+	e.GET("/.well-known/jwks.json", func(c echo.Context) error {
+		return c.JSON(200, jwtInstance.JWKS())
+	})
 	authRouter := basePathGroup.Group("/auth")
 	meRouter := basePathGroup.Group("/me", authMiddleware)
+	groupsRouter := basePathGroup.Group("/groups", authMiddleware)
+	publicRouter := basePathGroup.Group("/public")
 
 	routers.AuthRouter.Route(authRouter, authMiddleware)
 	routers.MeRouter.Route(meRouter, authMiddleware)
+	routers.GroupsRouter.Route(groupsRouter, authMiddleware)
+	routers.PublicRouter.Route(publicRouter, authMiddleware)
 
 	e.GET("health", func(c echo.Context) error {
 		return c.JSON(200, "ok")
@@ -0,0 +1,63 @@
+package webapitest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"whereiseveryone/internal/attempts"
+	"whereiseveryone/pkg/timer"
+)
+
+// Attempts keeps counters in memory.
+type Attempts struct {
+	mu       sync.Mutex
+	timer    timer.Timer
+	Counters map[string]attempts.Attempts
+}
+
+func NewAttempts(timer timer.Timer) *Attempts {
+	return &Attempts{timer: timer, Counters: make(map[string]attempts.Attempts)}
+}
+
+func (f *Attempts) Begin(_ context.Context, key string, policy attempts.Policy) (attempts.Attempts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, ok := f.Counters[key]
+	if !ok {
+		current = attempts.Attempts{Key: key}
+	}
+	next, err := policy.Begin(f.timer.Now(), current)
+	if err != nil {
+		return next, err //nolint:wrapcheck // returned as is by the mongo adapter too
+	}
+	f.Counters[key] = next
+	return next, nil
+}
+
+func (f *Attempts) Release(_ context.Context, attempt attempts.Attempts) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, ok := f.Counters[attempt.Key]
+	if !ok {
+		return nil
+	}
+	current.Failures = max(current.Failures-1, 0)
+	if !attempt.LockedUntil.IsZero() && current.LockedUntil.Equal(attempt.LockedUntil) {
+		current.LockedUntil = time.Time{}
+	}
+	f.Counters[attempt.Key] = current
+	return nil
+}
+
+func (f *Attempts) Reset(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.Counters, key)
+	return nil
+}
+
+var _ attempts.Adapter = (*Attempts)(nil)
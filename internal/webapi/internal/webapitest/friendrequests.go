@@ -0,0 +1,92 @@
+package webapitest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"whereiseveryone/internal/friendrequests"
+	"whereiseveryone/pkg/id"
+)
+
+// FriendRequests keeps requests in memory, sending and accepting is implemented
+type FriendRequests struct {
+	mu       sync.Mutex
+	Requests []friendrequests.FriendRequest
+}
+
+func (f *FriendRequests) find(from, to id.ID) int {
+	return slices.IndexFunc(f.Requests, func(r friendrequests.FriendRequest) bool {
+		return r.From == from && r.To == to
+	})
+}
+
+func (f *FriendRequests) Create(
+	_ context.Context,
+	request friendrequests.FriendRequest,
+) (friendrequests.FriendRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.find(request.From, request.To) >= 0 {
+		return friendrequests.FriendRequest{}, friendrequests.ErrRequestExists
+	}
+	request.ID = id.NewID()
+	f.Requests = append(f.Requests, request)
+	return request, nil
+}
+
+func (f *FriendRequests) Find(_ context.Context, from, to id.ID) (friendrequests.FriendRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.find(from, to)
+	if i < 0 {
+		return friendrequests.FriendRequest{}, friendrequests.ErrRequestNotExists
+	}
+	return f.Requests[i], nil
+}
+
+func (f *FriendRequests) GetIncoming(context.Context, id.ID, id.ID) (friendrequests.FriendRequest, error) {
+	return friendrequests.FriendRequest{}, ErrNotImplemented
+}
+
+func (f *FriendRequests) Incoming(context.Context, id.ID) ([]friendrequests.FriendRequest, error) {
+	return nil, ErrNotImplemented
+}
+
+func (f *FriendRequests) Outgoing(context.Context, id.ID) ([]friendrequests.FriendRequest, error) {
+	return nil, ErrNotImplemented
+}
+
+func (f *FriendRequests) DeleteIncoming(
+	_ context.Context,
+	requestID, to id.ID,
+) (friendrequests.FriendRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := slices.IndexFunc(f.Requests, func(r friendrequests.FriendRequest) bool {
+		return r.ID == requestID && r.To == to
+	})
+	if i < 0 {
+		return friendrequests.FriendRequest{}, friendrequests.ErrRequestNotExists
+	}
+	request := f.Requests[i]
+	f.Requests = slices.Delete(f.Requests, i, i+1)
+	return request, nil
+}
+
+func (f *FriendRequests) DeleteOutgoing(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *FriendRequests) DeleteBetween(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *FriendRequests) DeleteUserRequests(context.Context, id.ID) error {
+	return ErrNotImplemented
+}
+
+var _ friendrequests.Adapter = (*FriendRequests)(nil)
@@ -0,0 +1,150 @@
+package webapitest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"whereiseveryone/internal/groups"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/pkg/id"
+)
+
+// Groups keeps groups in memory, changes are made on the given groups.
+// Member management is implemented.
+type Groups struct {
+	mu     sync.Mutex
+	groups []*groups.Group
+}
+
+func NewGroups(gs ...*groups.Group) *Groups {
+	return &Groups{groups: gs}
+}
+
+func (f *Groups) find(groupID id.ID) (*groups.Group, error) {
+	for _, g := range f.groups {
+		if g.ID == groupID {
+			return g, nil
+		}
+	}
+	return nil, groups.ErrGroupNotExists
+}
+
+func (f *Groups) member(groupID, userID id.ID) (*groups.Member, error) {
+	g, err := f.find(groupID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(g.Members, func(m groups.Member) bool { return m.UserID == userID })
+	if i < 0 {
+		return nil, groups.ErrMemberNotExists
+	}
+	return &g.Members[i], nil
+}
+
+func (f *Groups) Create(context.Context, groups.Group) (groups.Group, error) {
+	return groups.Group{}, ErrNotImplemented
+}
+
+func (f *Groups) Get(_ context.Context, groupID id.ID) (groups.Group, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	g, err := f.find(groupID)
+	if err != nil {
+		return groups.Group{}, err
+	}
+	group := *g
+	group.Members = slices.Clone(g.Members)
+	group.Invites = slices.Clone(g.Invites)
+	return group, nil
+}
+
+func (f *Groups) GetUserGroups(context.Context, id.ID) ([]groups.Group, error) {
+	return nil, ErrNotImplemented
+}
+
+func (f *Groups) GetInvitations(context.Context, id.ID) ([]groups.Group, error) {
+	return nil, ErrNotImplemented
+}
+
+func (f *Groups) Delete(context.Context, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Groups) Invite(context.Context, id.ID, groups.Invite) error {
+	return ErrNotImplemented
+}
+
+func (f *Groups) Join(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Groups) DeclineInvite(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Groups) RemoveMember(_ context.Context, groupID, userID id.ID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	g, err := f.find(groupID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(g.Members, func(m groups.Member) bool { return m.UserID == userID })
+	if i < 0 {
+		return groups.ErrMemberNotExists
+	}
+	g.Members = slices.Delete(g.Members, i, i+1)
+	return nil
+}
+
+func (f *Groups) SetRole(_ context.Context, groupID, userID id.ID, role groups.Role) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, err := f.member(groupID, userID)
+	if err != nil {
+		return err
+	}
+	m.Role = role
+	return nil
+}
+
+func (f *Groups) SetPrecision(_ context.Context, groupID, userID id.ID, precision users.Precision) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, err := f.member(groupID, userID)
+	if err != nil {
+		return err
+	}
+	m.Precision = precision
+	return nil
+}
+
+func (f *Groups) TransferOwnership(_ context.Context, groupID, owner, newOwner id.ID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, err := f.member(groupID, owner)
+	if err != nil {
+		return err
+	}
+	next, err := f.member(groupID, newOwner)
+	if err != nil {
+		return err
+	}
+	if current.Role != groups.RoleOwner {
+		return groups.ErrMemberNotExists
+	}
+	current.Role, next.Role = groups.RoleAdmin, groups.RoleOwner
+	return nil
+}
+
+func (f *Groups) DeleteUserGroups(context.Context, id.ID) error {
+	return ErrNotImplemented
+}
+
+var _ groups.Adapter = (*Groups)(nil)
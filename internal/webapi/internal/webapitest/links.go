@@ -0,0 +1,61 @@
+package webapitest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"whereiseveryone/internal/links"
+	"whereiseveryone/pkg/id"
+)
+
+// Links keeps links in memory, changes are made on the given links.
+// View records viewers without a limit, new viewers are rejected only when Full is set
+// (the limit itself is enforced by the mongo adapter).
+type Links struct {
+	mu    sync.Mutex
+	links []*links.Link
+	// Full makes View reject viewers who didn't open the link yet
+	Full bool
+}
+
+func NewLinks(ls ...*links.Link) *Links {
+	return &Links{links: ls}
+}
+
+func (f *Links) Create(context.Context, links.Link) (links.Link, error) {
+	return links.Link{}, ErrNotImplemented
+}
+
+func (f *Links) View(_ context.Context, userID, linkID id.ID, viewer string) (links.Link, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := slices.IndexFunc(f.links, func(l *links.Link) bool { return l.ID == linkID && l.UserID == userID })
+	if i < 0 {
+		return links.Link{}, links.ErrLinkNotExists
+	}
+	link := f.links[i]
+	if !slices.Contains(link.Viewers, viewer) {
+		if f.Full {
+			return links.Link{}, links.ErrTooManyViewers
+		}
+		link.Viewers = append(link.Viewers, viewer)
+	}
+	link.Views++
+	return *link, nil
+}
+
+func (f *Links) List(context.Context, id.ID) ([]links.Link, error) {
+	return nil, ErrNotImplemented
+}
+
+func (f *Links) Delete(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Links) DeleteUserLinks(context.Context, id.ID) error {
+	return ErrNotImplemented
+}
+
+var _ links.Adapter = (*Links)(nil)
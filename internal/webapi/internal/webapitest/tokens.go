@@ -0,0 +1,39 @@
+package webapitest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"whereiseveryone/internal/tokens"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
+)
+
+// Tokens records revoked sessions
+type Tokens struct {
+	mu              sync.Mutex
+	RevokedSessions []id.ID
+}
+
+func (f *Tokens) RevokeToken(context.Context, string, time.Time) error {
+	return ErrNotImplemented
+}
+
+func (f *Tokens) RevokeSession(_ context.Context, sessionID id.ID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.RevokedSessions = append(f.RevokedSessions, sessionID)
+	return nil
+}
+
+func (f *Tokens) RevokeUserTokens(context.Context, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Tokens) IsRevoked(context.Context, jwt.SignedToken) (bool, error) {
+	return false, ErrNotImplemented
+}
+
+var _ tokens.Adapter = (*Tokens)(nil)
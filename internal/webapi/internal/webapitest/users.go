@@ -0,0 +1,235 @@
+package webapitest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"whereiseveryone/internal/users"
+	"whereiseveryone/pkg/id"
+)
+
+// Users keeps users in memory, changes are made on the given users.
+// Lookups, observing and sessions are implemented.
+type Users struct {
+	mu    sync.Mutex
+	users []*users.User
+}
+
+func NewUsers(us ...*users.User) *Users {
+	return &Users{users: us}
+}
+
+func (f *Users) find(userID id.ID) (*users.User, error) {
+	for _, u := range f.users {
+		if u.ID == userID {
+			return u, nil
+		}
+	}
+	return nil, users.ErrUserNotExists
+}
+
+func (f *Users) NewUser(context.Context, users.User) (users.User, error) {
+	return users.User{}, ErrNotImplemented
+}
+
+func (f *Users) GetUser(_ context.Context, userID id.ID) (users.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u, err := f.find(userID)
+	if err != nil {
+		return users.User{}, err
+	}
+	return *u, nil
+}
+
+func (f *Users) GetUsers(_ context.Context, userIDs []id.ID) ([]users.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]users.User, 0, len(userIDs))
+	for _, u := range f.users {
+		if slices.Contains(userIDs, u.ID) {
+			result = append(result, *u)
+		}
+	}
+	return result, nil
+}
+
+func (f *Users) GetUserByUsername(_ context.Context, username string) (users.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, u := range f.users {
+		if u.Auth.Username == username {
+			return *u, nil
+		}
+	}
+	return users.User{}, users.ErrUserNotExists
+}
+
+func (f *Users) GetObservers(_ context.Context, userID id.ID) ([]users.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []users.User
+	for _, u := range f.users {
+		if u.SubscribeUser(userID) {
+			result = append(result, *u)
+		}
+	}
+	return result, nil
+}
+
+func (f *Users) UpdateStatus(context.Context, id.ID, string) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) ObserveUser(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UnobserveUser(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) ObserveEachOther(
+	ctx context.Context,
+	user, otherUser id.ID,
+	cleanups ...func(ctx context.Context) error,
+) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u, err := f.find(user)
+	if err != nil {
+		return err
+	}
+	other, err := f.find(otherUser)
+	if err != nil {
+		return err
+	}
+	for _, cleanup := range cleanups {
+		if err := cleanup(ctx); err != nil {
+			return err
+		}
+	}
+	u.SubscribedUsers = append(u.SubscribedUsers, otherUser)
+	other.SubscribedUsers = append(other.SubscribedUsers, user)
+	return nil
+}
+
+func (f *Users) BlockUser(context.Context, id.ID, id.ID, ...func(ctx context.Context) error) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UnblockUser(context.Context, id.ID, id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) DeleteUser(context.Context, id.ID, ...func(ctx context.Context) error) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UpdateLocation(context.Context, id.ID, users.Location) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) NewSession(_ context.Context, userID id.ID, session users.Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u, err := f.find(userID)
+	if err != nil {
+		return err
+	}
+	u.Auth.Sessions = append(u.Auth.Sessions, session)
+	return nil
+}
+
+func (f *Users) UpdateTokens(context.Context, id.ID, id.ID, *string, *string) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) RotateTokens(
+	_ context.Context,
+	userID, sessionID id.ID,
+	oldRefreshToken, token, refreshedToken string,
+) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u, err := f.find(userID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(u.Auth.Sessions, func(s users.Session) bool { return s.ID == sessionID })
+	if i < 0 || u.Auth.Sessions[i].RefreshToken != oldRefreshToken {
+		return users.ErrRefreshTokenReused
+	}
+	u.Auth.Sessions[i].Token, u.Auth.Sessions[i].RefreshToken = token, refreshedToken
+	return nil
+}
+
+func (f *Users) DeleteSession(_ context.Context, userID, sessionID id.ID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u, err := f.find(userID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(u.Auth.Sessions, func(s users.Session) bool { return s.ID == sessionID })
+	if i < 0 {
+		return users.ErrSessionNotExists
+	}
+	u.Auth.Sessions = slices.Delete(u.Auth.Sessions, i, i+1)
+	return nil
+}
+
+func (f *Users) DeleteSessions(context.Context, id.ID, ...id.ID) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UpdatePassword(context.Context, id.ID, string) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UpdateEmail(context.Context, id.ID, string) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) SetTwoFactor(context.Context, id.ID, *users.TwoFactor) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UseTOTPStep(context.Context, id.ID, int64) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UseRecoveryCode(context.Context, id.ID, string) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) GetUserByIdentity(context.Context, string, string) (users.User, error) {
+	return users.User{}, ErrNotImplemented
+}
+
+func (f *Users) LinkIdentity(context.Context, id.ID, users.Identity) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) UnlinkIdentity(context.Context, id.ID, string) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) SetLocationPrecision(context.Context, id.ID, id.ID, users.Precision) error {
+	return ErrNotImplemented
+}
+
+func (f *Users) SetGhost(context.Context, id.ID, *users.Ghost) error {
+	return ErrNotImplemented
+}
+
+var _ users.Adapter = (*Users)(nil)
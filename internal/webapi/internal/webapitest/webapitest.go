@@ -0,0 +1,81 @@
+// Package webapitest contains helpers and in-memory adapters for handler tests.
+// Adapter methods which are not needed by tests return ErrNotImplemented.
+package webapitest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"whereiseveryone/internal/webapi"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
+)
+
+// UserHeader tells who sends the request, see Auth
+const UserHeader = "X-User"
+
+var ErrNotImplemented = errors.New("not implemented in tests")
+
+// Timer returns the set time
+type Timer struct {
+	Time time.Time
+}
+
+func (t *Timer) Now() time.Time {
+	return t.Time
+}
+
+type echoValidator struct {
+	validator *validator.Validate
+}
+
+func (v echoValidator) Validate(i any) error {
+	return v.validator.Struct(i) //nolint:wrapcheck // that's ok (echo framework)
+}
+
+// NewEcho returns echo validating requests like the app does
+func NewEcho() *echo.Echo {
+	validate := validator.New()
+	if err := webapi.RegisterValidations(validate); err != nil {
+		panic(err)
+	}
+	e := echo.New()
+	e.Validator = echoValidator{validator: validate}
+
+	return e
+}
+
+// Auth is an auth middleware authenticating the user from UserHeader with all scopes
+func Auth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set("user", jwt.SignedToken{ID: c.Request().Header.Get(UserHeader), Scopes: webapi.AllScopes})
+		return next(c)
+	}
+}
+
+// Request sends the request with JSON body to echo, the zero user ID sends it without UserHeader
+func Request(
+	e *echo.Echo,
+	method, path, body string,
+	userID id.ID,
+	cookies ...*http.Cookie,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if userID != id.ZeroID {
+		req.Header.Set(UserHeader, userID.Hex())
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
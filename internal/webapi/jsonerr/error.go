@@ -62,10 +62,18 @@ func EchoInternalError(err error) *JSONError {
 	return EchoError(500, "internal error", err)
 }
 
+func EchoUnauthorizedError(err error) *JSONError {
+	return EchoError(401, "unauthorized", err)
+}
+
 func EchoForbiddenError() *JSONError {
 	return EchoError(403, "forbidden", nil)
 }
 
+func EchoTooManyRequestsError(err error) *JSONError {
+	return EchoError(429, "too many requests", err)
+}
+
 func EchoConflictError(err error) *JSONError {
 	return EchoError(409, "conflict", err)
 }
@@ -0,0 +1,65 @@
+package me
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+)
+
+// deleteAccount
+//
+// @summary delete account
+// @description removes the logged user permanently, the user is removed from other users' observed lists,
+// @description all user tokens are revoked and API keys removed.
+// @description Accounts created with OIDC have no password, they set it with the password reset first.
+// @tags me
+// @accept json
+// @param confirmation body deleteAccountRequest true "password confirmation"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 403 {object} jsonerr.JSONError "forbidden (invalid password or the account has no password)"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me [DELETE]
+func (m *mux) deleteAccount(c echo.Context) error {
+	request, bindErr := binder.BindRequest[deleteAccountRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	if err := m.verifyPassword(user, request.Request.Password); err != nil {
+		return err.Echo(c)
+	}
+
+	err = m.userAdapter.DeleteUser(request.Context(), user.ID,
+		func(txCtx context.Context) error {
+			return m.revokedTokens.RevokeUserTokens(txCtx, user.ID)
+		},
+		func(txCtx context.Context) error {
+			return m.apiKeys.DeleteUserKeys(txCtx, user.ID)
+		},
+		func(txCtx context.Context) error {
+			return m.friendRequests.DeleteUserRequests(txCtx, user.ID)
+		},
+		func(txCtx context.Context) error {
+			return m.groups.DeleteUserGroups(txCtx, user.ID)
+		},
+		func(txCtx context.Context) error {
+			return m.shares.DeleteUserShares(txCtx, user.ID)
+		},
+		func(txCtx context.Context) error {
+			return m.links.DeleteUserLinks(txCtx, user.ID)
+		},
+	)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
@@ -0,0 +1,137 @@
+package me
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/apikeys"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/id"
+)
+
+var ErrTooManyAPIKeys = errors.New("too many api keys, remove unused ones")
+
+// createAPIKey
+//
+// @summary create API key
+// @description creates a long-lived key for a device which can't log in (e.g. GPS tracker).
+// @description The key can be used only to update the location (send it in X-Api-Key header), it's shown only once.
+// @tags me
+// @accept json
+// @produce json
+// @param key body createAPIKeyRequest true "key details"
+// @success 201 {object} createAPIKeyResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 409 {object} jsonerr.JSONError "too many keys"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/api-keys [POST]
+func (m *mux) createAPIKey(c echo.Context) error {
+	request, bindErr := binder.BindRequest[createAPIKeyRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	keys, err := m.apiKeys.List(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if len(keys) >= m.config.MaxAPIKeys {
+		return jsonerr.EchoConflictError(ErrTooManyAPIKeys).Echo(c)
+	}
+
+	key, err := apikeys.NewKey()
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	created, err := m.apiKeys.Create(request.Context(), apikeys.APIKey{
+		UserID:    request.UserID(),
+		Name:      request.Request.Name,
+		KeyHash:   crypto.HashToken(key),
+		Hint:      apikeys.Hint(key),
+		CreatedAt: m.timer.Now(),
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusCreated, createAPIKeyResponse{
+		apiKeyDetails: toAPIKeyDetails(created),
+		Key:           key,
+	})
+}
+
+// getAPIKeys
+//
+// @summary get API keys
+// @description returns API keys of the user (without the keys themselves)
+// @tags me
+// @produce json
+// @success 200 {object} getAPIKeysResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/api-keys [GET]
+func (m *mux) getAPIKeys(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	keys, err := m.apiKeys.List(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result := make(getAPIKeysResponse, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, toAPIKeyDetails(k))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// deleteAPIKey
+//
+// @summary delete API key
+// @description revokes the API key, it cannot be used anymore
+// @tags me
+// @param id path string true "key id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "key not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/api-keys/{id} [DELETE]
+func (m *mux) deleteAPIKey(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	keyID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if err := m.apiKeys.Delete(request.Context(), request.UserID(), keyID); err != nil {
+		if errors.Is(err, apikeys.ErrKeyNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+func toAPIKeyDetails(k apikeys.APIKey) apiKeyDetails {
+	return apiKeyDetails{
+		ID:         k.ID.Hex(),
+		Name:       k.Name,
+		Hint:       k.Hint,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+	}
+}
@@ -0,0 +1,149 @@
+package me
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+)
+
+var (
+	ErrCannotBlockSelf = errors.New("cannot block yourself")
+	ErrUserBlocked     = errors.New("user is blocked, unblock the user first")
+)
+
+// blockUser
+//
+// @summary block the user
+// @description blocks the user, users stop observing each other, pending friend requests and location shares
+// @description between them are removed.
+// @description The blocked user cannot observe nor send friend requests to the requester,
+// @description the requester is not found for them.
+// @tags me
+// @accept json
+// @param user body blockRequest true "user to block"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "requested user not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/blocks [POST]
+func (m *mux) blockUser(c echo.Context) error {
+	request, bindErr := binder.BindRequest[blockRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	userToBlock, err := m.lookupUser(request.Context(), request.UserID(), request.Request.Username)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if userToBlock.ID == request.UserID() {
+		return jsonerr.EchoInvalidRequestError(ErrCannotBlockSelf).Echo(c)
+	}
+
+	err = m.userAdapter.BlockUser(request.Context(), request.UserID(), userToBlock.ID,
+		func(txCtx context.Context) error {
+			return m.friendRequests.DeleteBetween(txCtx, request.UserID(), userToBlock.ID)
+		},
+		func(txCtx context.Context) error {
+			return m.shares.DeleteBetween(txCtx, request.UserID(), userToBlock.ID)
+		},
+	)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// unblockUser
+//
+// @summary unblock the user
+// @description unblocks the user, previous relationship is not restored. If user is not blocked, nothing happen
+// @tags me
+// @accept json
+// @param user body blockRequest true "user to unblock"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "requested user not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/blocks [DELETE]
+func (m *mux) unblockUser(c echo.Context) error {
+	request, bindErr := binder.BindRequest[blockRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	userToUnblock, err := m.userAdapter.GetUserByUsername(request.Context(), request.Request.Username)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	err = m.userAdapter.UnblockUser(request.Context(), request.UserID(), userToUnblock.ID)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// getBlocks
+//
+// @summary get blocked users
+// @description returns users blocked by the requester
+// @tags me
+// @produce json
+// @success 200 {object} getBlocksResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/blocks [GET]
+func (m *mux) getBlocks(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	blocked, err := m.userAdapter.GetUsers(request.Context(), user.BlockedUsers)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result := make(getBlocksResponse, 0, len(blocked))
+	for _, u := range blocked {
+		result = append(result, blockedUserDetails{
+			Username: u.Auth.Username,
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// lookupUser returns the user by username, users who blocked the requester are reported as not existing
+func (m *mux) lookupUser(ctx context.Context, requester id.ID, username string) (users.User, error) {
+	u, err := m.userAdapter.GetUserByUsername(ctx, username)
+	if err != nil {
+		return users.User{}, err
+	}
+	if u.Blocks(requester) {
+		return users.User{}, users.ErrUserNotExists
+	}
+
+	return u, nil
+}
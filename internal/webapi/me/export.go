@@ -0,0 +1,144 @@
+package me
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+)
+
+const exportFileName = "whereiseveryone-export"
+
+// export
+//
+// @summary export personal data
+// @description returns all data stored about the logged user (secrets like password hash and tokens are omitted),
+// @description with `format=zip` the data is returned as a zip archive with a single json file
+// @tags me
+// @produce json
+// @produce application/zip
+// @param format query string false "export format" Enums(json, zip)
+// @success 200 {object} exportResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/export [GET]
+func (m *mux) export(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "zip" {
+		return jsonerr.EchoInvalidRequestError(fmt.Errorf("unsupported format: %s", format)).Echo(c)
+	}
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	observed, err := m.userAdapter.GetUsers(request.Context(), user.SubscribedUsers)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	observers, err := m.userAdapter.GetObservers(request.Context(), user.ID)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	blocked, err := m.userAdapter.GetUsers(request.Context(), user.BlockedUsers)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	userGroups, err := m.groups.GetUserGroups(request.Context(), user.ID)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	userShares, err := m.sharesDetails(request.Context(), user.ID)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	userLinks, err := m.links.List(request.Context(), user.ID)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	keys, err := m.apiKeys.List(request.Context(), user.ID)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result := exportResponse{
+		ID: user.ID.Hex(),
+		Auth: exportAuth{
+			Username:         user.Auth.Username,
+			Email:            user.Auth.Email,
+			TwoFactorEnabled: user.Auth.TwoFactor.IsEnabled(),
+			Sessions:         make([]sessionDetails, 0, len(user.Auth.Sessions)),
+			CreatedAt:        user.Auth.CreatedAt,
+			UpdatedAt:        user.Auth.UpdatedAt,
+		},
+		Status:     user.Status,
+		Location:   toLocationDetails(user.Location),
+		Observed:   usernames(observed),
+		Observers:  usernames(observers),
+		Blocked:    usernames(blocked),
+		Groups:     make([]string, 0, len(userGroups)),
+		Shares:     userShares,
+		Ghost:      toGhostResponse(user.Ghost, m.timer.Now()),
+		Links:      toLinksDetails(userLinks),
+		Identities: toIdentityDetails(user.Identities),
+		APIKeys:    make([]apiKeyDetails, 0, len(keys)),
+		ExportedAt: m.timer.Now(),
+	}
+	for _, g := range userGroups {
+		result.Groups = append(result.Groups, g.Name)
+	}
+	for _, s := range user.Auth.Sessions {
+		result.Auth.Sessions = append(result.Auth.Sessions, toSessionDetails(s, request.TokenData().SessionID))
+	}
+	for _, k := range keys {
+		result.APIKeys = append(result.APIKeys, toAPIKeyDetails(k))
+	}
+
+	if format != "zip" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", exportFileName+".json"))
+		return c.JSON(http.StatusOK, result)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", exportFileName+".zip"))
+	c.Response().WriteHeader(http.StatusOK)
+
+	archive := zip.NewWriter(c.Response())
+	f, err := archive.Create(exportFileName + ".json")
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return fmt.Errorf("write export file: %w", err)
+	}
+
+	return archive.Close()
+}
+
+func usernames(us []users.User) []string {
+	result := make([]string, 0, len(us))
+	for _, u := range us {
+		result = append(result, u.Auth.Username)
+	}
+
+	return result
+}
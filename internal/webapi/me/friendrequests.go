@@ -0,0 +1,320 @@
+package me
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/friendrequests"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+)
+
+var (
+	ErrCannotBefriendSelf = errors.New("cannot send friend request to yourself")
+	ErrAlreadyFriends     = errors.New("users already observe each other")
+)
+
+// sendFriendRequest
+//
+// @summary send friend request
+// @description sends a request to observe each other. If the user has already sent a request to the requester,
+// @description it's accepted instead (204).
+// @tags me
+// @accept json
+// @produce json
+// @param user body friendRequestRequest true "user to send the request to"
+// @success 201 {object} friendRequestDetails
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "requested user not exists"
+// @failure 409 {object} jsonerr.JSONError "request already sent, users are friends already or the user is blocked"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/friend-requests [POST]
+func (m *mux) sendFriendRequest(c echo.Context) error {
+	request, bindErr := binder.BindRequest[friendRequestRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	to, err := m.lookupUser(request.Context(), request.UserID(), request.Request.Username)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	sent, err := m.befriend(request.Context(), request.UserID(), to)
+	if err != nil {
+		return friendRequestError(err).Echo(c)
+	}
+	if sent == nil {
+		return c.NoContent(204)
+	}
+
+	return c.JSON(http.StatusCreated, toFriendRequestDetails(*sent, to.Auth.Username))
+}
+
+// getIncomingFriendRequests
+//
+// @summary get incoming friend requests
+// @description returns pending requests sent to the user
+// @tags me
+// @produce json
+// @success 200 {object} getFriendRequestsResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/friend-requests/incoming [GET]
+func (m *mux) getIncomingFriendRequests(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	requests, err := m.friendRequests.Incoming(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result, err := m.friendRequestsDetails(request.Context(), requests, func(r friendrequests.FriendRequest) id.ID {
+		return r.From
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// getOutgoingFriendRequests
+//
+// @summary get outgoing friend requests
+// @description returns pending requests sent by the user
+// @tags me
+// @produce json
+// @success 200 {object} getFriendRequestsResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/friend-requests/outgoing [GET]
+func (m *mux) getOutgoingFriendRequests(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	requests, err := m.friendRequests.Outgoing(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result, err := m.friendRequestsDetails(request.Context(), requests, func(r friendrequests.FriendRequest) id.ID {
+		return r.To
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// acceptFriendRequest
+//
+// @summary accept friend request
+// @description accepts incoming request, users start observing each other
+// @tags me
+// @param id path string true "request id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "request not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/friend-requests/{id}/accept [POST]
+func (m *mux) acceptFriendRequest(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	requestID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	friendRequest, err := m.friendRequests.GetIncoming(request.Context(), requestID, request.UserID())
+	if err != nil {
+		return friendRequestError(err).Echo(c)
+	}
+
+	if err := m.accept(request.Context(), friendRequest); err != nil {
+		return friendRequestError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// declineFriendRequest
+//
+// @summary decline friend request
+// @description removes incoming request, the sender is not notified
+// @tags me
+// @param id path string true "request id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "request not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/friend-requests/{id}/decline [POST]
+func (m *mux) declineFriendRequest(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	requestID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if _, err := m.friendRequests.DeleteIncoming(request.Context(), requestID, request.UserID()); err != nil {
+		return friendRequestError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// cancelFriendRequest
+//
+// @summary cancel friend request
+// @description removes outgoing request
+// @tags me
+// @param id path string true "request id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "request not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/friend-requests/{id} [DELETE]
+func (m *mux) cancelFriendRequest(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	requestID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if err := m.friendRequests.DeleteOutgoing(request.Context(), requestID, request.UserID()); err != nil {
+		return friendRequestError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// befriend sends a friend request to the user, or accepts the request sent by the user.
+// Returns the sent request, nil if the users became friends.
+func (m *mux) befriend(ctx context.Context, from id.ID, to users.User) (*friendrequests.FriendRequest, error) {
+	if from == to.ID {
+		return nil, ErrCannotBefriendSelf
+	}
+	if to.Blocks(from) {
+		return nil, users.ErrUserNotExists
+	}
+
+	requester, err := m.userAdapter.GetUser(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if requester.Blocks(to.ID) {
+		return nil, ErrUserBlocked
+	}
+	if requester.SubscribeUser(to.ID) && to.SubscribeUser(from) {
+		return nil, ErrAlreadyFriends
+	}
+
+	reverse, err := m.friendRequests.Find(ctx, to.ID, from)
+	if err == nil {
+		return nil, m.accept(ctx, reverse)
+	}
+	if !errors.Is(err, friendrequests.ErrRequestNotExists) {
+		return nil, err
+	}
+
+	now := m.timer.Now()
+	sent, err := m.friendRequests.Create(ctx, friendrequests.FriendRequest{
+		From:      from,
+		To:        to.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.config.FriendRequestValidity),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sent, nil
+}
+
+// accept makes users observe each other and removes the request in a single transaction
+func (m *mux) accept(ctx context.Context, request friendrequests.FriendRequest) error {
+	return m.userAdapter.ObserveEachOther(ctx, request.To, request.From, func(txCtx context.Context) error {
+		_, err := m.friendRequests.DeleteIncoming(txCtx, request.ID, request.To)
+		return err
+	})
+}
+
+func (m *mux) friendRequestsDetails(
+	ctx context.Context,
+	requests []friendrequests.FriendRequest,
+	otherUser func(r friendrequests.FriendRequest) id.ID,
+) (getFriendRequestsResponse, error) {
+	ids := make([]id.ID, 0, len(requests))
+	for _, r := range requests {
+		ids = append(ids, otherUser(r))
+	}
+
+	others, err := m.userAdapter.GetUsers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[id.ID]string, len(others))
+	for _, u := range others {
+		usernames[u.ID] = u.Auth.Username
+	}
+
+	result := make(getFriendRequestsResponse, 0, len(requests))
+	for _, r := range requests {
+		if username, ok := usernames[otherUser(r)]; ok {
+			result = append(result, toFriendRequestDetails(r, username))
+		}
+	}
+
+	return result, nil
+}
+
+func toFriendRequestDetails(r friendrequests.FriendRequest, username string) friendRequestDetails {
+	return friendRequestDetails{
+		ID:        r.ID.Hex(),
+		Username:  username,
+		CreatedAt: r.CreatedAt,
+		ExpiresAt: r.ExpiresAt,
+	}
+}
+
+func friendRequestError(err error) *jsonerr.JSONError {
+	switch {
+	case errors.Is(err, ErrCannotBefriendSelf):
+		return jsonerr.EchoInvalidRequestError(err)
+	case errors.Is(err, friendrequests.ErrRequestNotExists), errors.Is(err, users.ErrUserNotExists):
+		return jsonerr.EchoNotFoundError(err)
+	case errors.Is(err, friendrequests.ErrRequestExists), errors.Is(err, ErrAlreadyFriends),
+		errors.Is(err, ErrUserBlocked):
+		return jsonerr.EchoConflictError(err)
+	default:
+		return jsonerr.EchoInternalError(err)
+	}
+}
@@ -0,0 +1,59 @@
+package me
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/internal/webapitest"
+	"whereiseveryone/pkg/id"
+)
+
+func Test_SendFriendRequest(t *testing.T) {
+	alice := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "alice"}}
+	bob := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "bob"}}
+	carol := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "carol"}, BlockedUsers: []id.ID{alice.ID}}
+	requests := &webapitest.FriendRequests{}
+
+	e := webapitest.NewEcho()
+	tm := &webapitest.Timer{Time: time.Now()}
+	mux := NewMux(webapitest.NewUsers(alice, bob, carol), nil, nil, requests, nil, nil, nil, nil, tm, nil,
+		Config{FriendRequestValidity: time.Hour})
+	mux.Route(e.Group("/me", webapitest.Auth), webapitest.Auth)
+
+	send := func(from *users.User, username string) *httptest.ResponseRecorder {
+		return webapitest.Request(e, http.MethodPost, "/me/friend-requests", `{"username":"`+username+`"}`, from.ID)
+	}
+
+	if rec := send(alice, "bob"); rec.Code != http.StatusCreated {
+		t.Fatalf("send request: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if rec := send(alice, "bob"); rec.Code != http.StatusConflict {
+		t.Fatalf("send request again: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	// the reverse request accepts the pending one
+	if rec := send(bob, "alice"); rec.Code != http.StatusNoContent {
+		t.Fatalf("send reverse request: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if !alice.SubscribeUser(bob.ID) || !bob.SubscribeUser(alice.ID) {
+		t.Fatalf("users should observe each other")
+	}
+	if len(requests.Requests) != 0 {
+		t.Fatalf("accepted request should be removed, requests: %+v", requests.Requests)
+	}
+	if rec := send(alice, "bob"); rec.Code != http.StatusConflict {
+		t.Fatalf("send request to a friend: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	// the user who blocked the requester is not distinguishable from a user who doesn't exist
+	blocked, missing := send(alice, "carol"), send(alice, "nobody")
+	if blocked.Code != http.StatusNotFound || blocked.Body.String() != missing.Body.String() {
+		t.Fatalf("blocked: status %d, body: %s, missing user body: %s", blocked.Code, blocked.Body.String(), missing.Body.String())
+	}
+	if len(requests.Requests) != 0 {
+		t.Fatalf("request to the blocker should not be created, requests: %+v", requests.Requests)
+	}
+}
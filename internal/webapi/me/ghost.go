@@ -0,0 +1,130 @@
+package me
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+)
+
+var ErrInvalidGhostUntil = errors.New("ghost mode end must be in the future")
+
+// getGhost
+//
+// @summary get ghost mode
+// @description returns the location sharing pause state
+// @tags me
+// @produce json
+// @success 200 {object} ghostResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/ghost [GET]
+func (m *mux) getGhost(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, toGhostResponse(user.Ghost, m.timer.Now()))
+}
+
+// setGhost
+//
+// @summary enable ghost mode
+// @description pauses the location sharing with everyone (friends, groups and shares) without unfriending anyone,
+// @description indefinitely or until the given time. In hidden mode the location is not shown,
+// @description in frozen mode the location from the time of the pause is shown.
+// @description The location can be still updated, it's shown again when the sharing is resumed.
+// @tags me
+// @accept json
+// @produce json
+// @param ghost body setGhostRequest true "ghost mode"
+// @success 200 {object} ghostResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/ghost [PUT]
+func (m *mux) setGhost(c echo.Context) error {
+	request, bindErr := binder.BindRequest[setGhostRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	now := m.timer.Now()
+	until := request.Request.Until
+	if until != nil {
+		if !until.After(now) {
+			return jsonerr.EchoInvalidRequestError(ErrInvalidGhostUntil).Echo(c)
+		}
+		utc := until.UTC()
+		until = &utc
+	}
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	ghost := &users.Ghost{
+		Mode:  users.GhostMode(request.Request.Mode),
+		Since: now,
+		Until: until,
+	}
+	if ghost.Mode == users.GhostFrozen {
+		ghost.FrozenLocation = user.Location
+		if user.Ghost.IsActive(now) && user.Ghost.Mode == users.GhostFrozen {
+			// keep the location frozen at the first pause, the user location could be updated since then
+			ghost.Since = user.Ghost.Since
+			ghost.FrozenLocation = user.Ghost.FrozenLocation
+		}
+	}
+
+	if err := m.userAdapter.SetGhost(request.Context(), request.UserID(), ghost); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, toGhostResponse(ghost, now))
+}
+
+// disableGhost
+//
+// @summary disable ghost mode
+// @description resumes the location sharing, if it's not paused nothing happens
+// @tags me
+// @success 204
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/ghost [DELETE]
+func (m *mux) disableGhost(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	if err := m.userAdapter.SetGhost(request.Context(), request.UserID(), nil); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+func toGhostResponse(ghost *users.Ghost, now time.Time) ghostResponse {
+	if !ghost.IsActive(now) {
+		return ghostResponse{}
+	}
+
+	return ghostResponse{
+		Enabled: true,
+		Mode:    string(ghost.Mode),
+		Since:   &ghost.Since,
+		Until:   ghost.Until,
+	}
+}
@@ -0,0 +1,88 @@
+package me
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+)
+
+var ErrLastLoginMethod = errors.New("the identity is the only way to log in, set a password first")
+
+// getIdentities
+//
+// @summary get linked identities
+// @description returns external (OIDC) identities linked to the user, see /auth/oidc/{provider}/link
+// @tags me
+// @produce json
+// @success 200 {object} getIdentitiesResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/identities [GET]
+func (m *mux) getIdentities(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, getIdentitiesResponse(toIdentityDetails(user.Identities)))
+}
+
+// unlinkIdentity
+//
+// @summary unlink identity
+// @description removes the provider identity from the user,
+// @description the last identity of user without a password cannot be removed
+// @tags me
+// @param provider path string true "provider name"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "identity is not linked"
+// @failure 409 {object} jsonerr.JSONError "the identity is the only way to log in"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/identities/{provider} [DELETE]
+func (m *mux) unlinkIdentity(c echo.Context) error {
+	request, bindErr := binder.BindRequest[unlinkIdentityRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if user.Auth.Password == "" && len(user.Identities) <= 1 {
+		return jsonerr.EchoConflictError(ErrLastLoginMethod).Echo(c)
+	}
+
+	if err := m.userAdapter.UnlinkIdentity(request.Context(), user.ID, request.Request.Provider); err != nil {
+		if errors.Is(err, users.ErrIdentityNotLinked) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+func toIdentityDetails(identities []users.Identity) []identityDetails {
+	result := make([]identityDetails, 0, len(identities))
+	for _, i := range identities {
+		result = append(result, identityDetails{
+			Provider: i.Provider,
+			Subject:  i.Subject,
+			Email:    i.Email,
+			LinkedAt: i.LinkedAt,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,160 @@
+package me
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/links"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+)
+
+var ErrTooManyLinks = errors.New("too many share links, revoke unused ones")
+
+// createLink
+//
+// @summary create share link
+// @description creates a public link to the live location for people without an account (GET /public/share/{token}).
+// @description The token is shown only once, the link can be opened by at most max_viewers distinct viewers
+// @description and works until it expires or is revoked.
+// @tags me
+// @accept json
+// @produce json
+// @param link body createLinkRequest true "link details"
+// @success 201 {object} createLinkResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request (e.g. expiry in the past or too far)"
+// @failure 409 {object} jsonerr.JSONError "too many links"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/links [POST]
+func (m *mux) createLink(c echo.Context) error {
+	request, bindErr := binder.BindRequest[createLinkRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	now := m.timer.Now()
+	expiresAt := request.Request.ExpiresAt.UTC()
+	if !expiresAt.After(now) || expiresAt.Sub(now) > m.config.MaxShareValidity {
+		err := fmt.Errorf("%w: must be in the future and at most %s from now",
+			ErrInvalidShareExpiry, m.config.MaxShareValidity)
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	userLinks, err := m.links.List(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if len(userLinks) >= m.config.MaxLinks {
+		return jsonerr.EchoConflictError(ErrTooManyLinks).Echo(c)
+	}
+
+	precision := request.Request.Precision
+	if precision == "" {
+		precision = string(users.PrecisionExact)
+	}
+
+	link, err := m.links.Create(request.Context(), links.Link{
+		UserID:     request.UserID(),
+		Name:       request.Request.Name,
+		Precision:  precision,
+		MaxViewers: request.Request.MaxViewers,
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	token, err := m.jwt.GenerateShareLinkToken(request.UserID(), link.ID, link.ExpiresAt)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusCreated, createLinkResponse{
+		linkDetails: toLinkDetails(link),
+		Token:       token,
+	})
+}
+
+// getLinks
+//
+// @summary get share links
+// @description returns active share links of the user with their view counts
+// @tags me
+// @produce json
+// @success 200 {object} getLinksResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/links [GET]
+func (m *mux) getLinks(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	userLinks, err := m.links.List(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, toLinksDetails(userLinks))
+}
+
+// deleteLink
+//
+// @summary revoke share link
+// @description revokes the share link, it can't be opened anymore
+// @tags me
+// @param id path string true "link id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "link not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/links/{id} [DELETE]
+func (m *mux) deleteLink(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	linkID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if err := m.links.Delete(request.Context(), request.UserID(), linkID); err != nil {
+		if errors.Is(err, links.ErrLinkNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+func toLinksDetails(ls []links.Link) getLinksResponse {
+	result := make(getLinksResponse, 0, len(ls))
+	for _, l := range ls {
+		result = append(result, toLinkDetails(l))
+	}
+
+	return result
+}
+
+func toLinkDetails(l links.Link) linkDetails {
+	return linkDetails{
+		ID:         l.ID.Hex(),
+		Name:       l.Name,
+		Precision:  l.Precision,
+		MaxViewers: l.MaxViewers,
+		Viewers:    len(l.Viewers),
+		Views:      l.Views,
+		CreatedAt:  l.CreatedAt,
+		ExpiresAt:  l.ExpiresAt,
+	}
+}
@@ -4,27 +4,128 @@ import (
 	"errors"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"time"
+	"whereiseveryone/internal/apikeys"
+	"whereiseveryone/internal/friendrequests"
+	"whereiseveryone/internal/groups"
+	"whereiseveryone/internal/links"
+	"whereiseveryone/internal/shares"
+	"whereiseveryone/internal/tokens"
 	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi"
 	"whereiseveryone/internal/webapi/binder"
 	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
 	"whereiseveryone/pkg/timer"
+	"whereiseveryone/pkg/totp"
 )
 
+// Config is a configuration of me endpoints
+type Config struct {
+	// TOTPIssuer is an issuer name shown in authenticator apps
+	TOTPIssuer string
+	// FriendRequestValidity is a time after which pending friend requests expire
+	FriendRequestValidity time.Duration
+	// MaxShareValidity is the longest time the location can be shared with a user who is not a friend
+	// or with a public link
+	MaxShareValidity time.Duration
+	// MaxAPIKeys is a maximum number of API keys of a single user
+	MaxAPIKeys int
+	// MaxLinks is a maximum number of active share links of a single user
+	MaxLinks int
+}
+
 type mux struct {
-	userAdapter users.Adapter
-	timer       timer.Timer
+	userAdapter    users.Adapter
+	revokedTokens  tokens.Adapter
+	apiKeys        apikeys.Adapter
+	friendRequests friendrequests.Adapter
+	groups         groups.Adapter
+	shares         shares.Adapter
+	links          links.Adapter
+	hasher         *crypto.Hasher
+	otp            totp.TOTP
+	timer          timer.Timer
+	jwt            *jwt.JWT
+	config         Config
 }
 
-func NewMux(userAdapter users.Adapter, timer timer.Timer) *mux {
-	return &mux{userAdapter: userAdapter, timer: timer}
+func NewMux(
+	userAdapter users.Adapter,
+	revokedTokens tokens.Adapter,
+	apiKeys apikeys.Adapter,
+	friendRequests friendrequests.Adapter,
+	groups groups.Adapter,
+	shares shares.Adapter,
+	links links.Adapter,
+	hasher *crypto.Hasher,
+	timer timer.Timer,
+	jwt *jwt.JWT,
+	config Config,
+) *mux {
+	return &mux{
+		userAdapter:    userAdapter,
+		revokedTokens:  revokedTokens,
+		apiKeys:        apiKeys,
+		friendRequests: friendRequests,
+		groups:         groups,
+		shares:         shares,
+		links:          links,
+		hasher:         hasher,
+		otp:            totp.New(),
+		timer:          timer,
+		jwt:            jwt,
+		config:         config,
+	}
 }
 
 func (m *mux) Route(g *echo.Group, _ echo.MiddlewareFunc) {
-	g.PUT("/status", m.updateStatus)
-	g.GET("/friends", m.getFriends)
-	g.PUT("/location", m.updateLocation)
-	g.POST("/observe", m.observe)
-	g.DELETE("/observe", m.unobserve)
+	profileRead := webapi.RequireScopes(webapi.ScopeProfileRead)
+	profileWrite := webapi.RequireScopes(webapi.ScopeProfileWrite)
+	friendsRead := webapi.RequireScopes(webapi.ScopeFriendsRead)
+	friendsWrite := webapi.RequireScopes(webapi.ScopeFriendsWrite)
+
+	g.PUT("/status", m.updateStatus, profileWrite)
+	g.GET("/friends", m.getFriends, friendsRead)
+	g.GET("/relationships", m.getRelationships, friendsRead)
+	g.PUT("/precision", m.setPrecision, friendsWrite)
+	g.GET("/ghost", m.getGhost, profileRead)
+	g.PUT("/ghost", m.setGhost, profileWrite)
+	g.DELETE("/ghost", m.disableGhost, profileWrite)
+	g.POST("/shares", m.createShare, friendsWrite)
+	g.GET("/shares", m.getShares, friendsRead)
+	g.DELETE("/shares/:id", m.deleteShare, friendsWrite)
+	g.POST("/links", m.createLink, friendsWrite)
+	g.GET("/links", m.getLinks, friendsRead)
+	g.DELETE("/links/:id", m.deleteLink, friendsWrite)
+	g.PUT("/location", m.updateLocation, webapi.RequireScopes(webapi.ScopeLocationWrite))
+	g.POST("/observe", m.observe, friendsWrite)
+	g.DELETE("/observe", m.unobserve, friendsWrite)
+	g.POST("/friend-requests", m.sendFriendRequest, friendsWrite)
+	g.GET("/friend-requests/incoming", m.getIncomingFriendRequests, friendsRead)
+	g.GET("/friend-requests/outgoing", m.getOutgoingFriendRequests, friendsRead)
+	g.POST("/friend-requests/:id/accept", m.acceptFriendRequest, friendsWrite)
+	g.POST("/friend-requests/:id/decline", m.declineFriendRequest, friendsWrite)
+	g.DELETE("/friend-requests/:id", m.cancelFriendRequest, friendsWrite)
+	g.GET("/blocks", m.getBlocks, friendsRead)
+	g.POST("/blocks", m.blockUser, friendsWrite)
+	g.DELETE("/blocks", m.unblockUser, friendsWrite)
+	g.GET("/sessions", m.getSessions, profileRead)
+	g.DELETE("/sessions/:id", m.deleteSession, profileWrite)
+	g.PUT("/password", m.changePassword, profileWrite)
+	g.PUT("/email", m.updateEmail, profileWrite)
+	g.DELETE("", m.deleteAccount, profileWrite)
+	g.GET("/export", m.export, profileRead)
+	g.POST("/2fa", m.enrollTwoFactor, profileWrite)
+	g.POST("/2fa/verify", m.verifyTwoFactor, profileWrite)
+	g.DELETE("/2fa", m.disableTwoFactor, profileWrite)
+	g.GET("/identities", m.getIdentities, profileRead)
+	g.DELETE("/identities/:provider", m.unlinkIdentity, profileWrite)
+	g.POST("/api-keys", m.createAPIKey, profileWrite)
+	g.GET("/api-keys", m.getAPIKeys, profileRead)
+	g.DELETE("/api-keys/:id", m.deleteAPIKey, profileWrite)
 }
 
 // updateStatus
@@ -60,7 +161,10 @@ func (m *mux) updateStatus(c echo.Context) error {
 // getFriends
 //
 // @summary get friends details
-// @description returns all details about observed users
+// @description returns all details about friends (users observing each other) and users sharing their location
+// @description with the requester for a limited time (see /me/shares). The location has the precision set by the user
+// @description (null if the user shares the status only). Users in ghost mode have the location hidden
+// @description or frozen at the time they paused the sharing.
 // @tags me
 // @produce json
 // @success 200 {object} getFriendsResponse
@@ -78,30 +182,46 @@ func (m *mux) getFriends(c echo.Context) error {
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
+	sharedWith, err := m.shares.SharedWith(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	sharedUntil := make(map[id.ID]time.Time, len(sharedWith))
+	for _, s := range sharedWith {
+		sharedUntil[s.From] = s.ExpiresAt
+	}
+
 	observedUsersIDs := user.SubscribedUsers
+	for sharerID := range sharedUntil {
+		if !user.SubscribeUser(sharerID) {
+			observedUsersIDs = append(observedUsersIDs, sharerID)
+		}
+	}
 	observedUsers, err := m.userAdapter.GetUsers(request.Context(), observedUsersIDs)
 	if err != nil {
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
+	now := m.timer.Now()
 	var result getFriendsResponse
 	for _, u := range observedUsers {
-		if !u.SubscribeUser(request.UserID()) {
+		until, shared := sharedUntil[u.ID]
+		mutual := u.SubscribeUser(request.UserID()) && user.SubscribeUser(u.ID)
+		if !mutual && !shared {
 			continue
 		}
 
-		result = append(result, friendDetails{
-			Username: u.Auth.Username,
-			Status:   u.Status,
-			Location: locationDetails{
-				Longitude:  u.Location.Longitude,
-				Latitude:   u.Location.Latitude,
-				Altitude:   u.Location.Altitude,
-				Bearing:    u.Location.Bearing,
-				Accuracy:   u.Location.Accuracy,
-				LastUpdate: u.Location.LastUpdate,
-			},
-		})
+		details := friendDetails{
+			Username:  u.Auth.Username,
+			Status:    u.Status,
+			Location:  toLocationDetails(u.LocationFor(request.UserID(), now)),
+			Precision: string(u.PrecisionFor(request.UserID())),
+			Hidden:    u.LocationHidden(now),
+		}
+		if !mutual {
+			details.SharedUntil = &until
+		}
+		result = append(result, details)
 	}
 
 	return c.JSON(http.StatusOK, result)
@@ -144,7 +264,8 @@ func (m *mux) updateLocation(c echo.Context) error {
 // observe
 //
 // @summary observe the user
-// @description start observing the user, the second user must observe requester too to get his details
+// @description sends a friend request to the user (see /me/friend-requests), users observe each other
+// @description after the request is accepted. Nothing happens if the request is already sent or users are friends.
 // @tags me
 // @accept json
 // @param user body observeRequest true "user to observe"
@@ -160,7 +281,7 @@ func (m *mux) observe(c echo.Context) error {
 	}
 	defer request.Cancel()
 
-	userToObserve, err := m.userAdapter.GetUserByUsername(request.Context(), request.Request.Username)
+	userToObserve, err := m.lookupUser(request.Context(), request.UserID(), request.Request.Username)
 	if err != nil {
 		if errors.Is(err, users.ErrUserNotExists) {
 			return jsonerr.EchoNotFoundError(err).Echo(c)
@@ -168,9 +289,9 @@ func (m *mux) observe(c echo.Context) error {
 		return jsonerr.EchoInternalError(err).Echo(c)
 	}
 
-	err = m.userAdapter.ObserveUser(request.Context(), request.UserID(), userToObserve.ID)
-	if err != nil {
-		return jsonerr.EchoInternalError(err).Echo(c)
+	_, err = m.befriend(request.Context(), request.UserID(), userToObserve)
+	if err != nil && !errors.Is(err, friendrequests.ErrRequestExists) && !errors.Is(err, ErrAlreadyFriends) {
+		return friendRequestError(err).Echo(c)
 	}
 
 	return c.NoContent(204)
@@ -195,7 +316,7 @@ func (m *mux) unobserve(c echo.Context) error {
 	}
 	defer request.Cancel()
 
-	userToUnobserve, err := m.userAdapter.GetUserByUsername(request.Context(), request.Request.Username)
+	userToUnobserve, err := m.lookupUser(request.Context(), request.UserID(), request.Request.Username)
 	if err != nil {
 		if errors.Is(err, users.ErrUserNotExists) {
 			return jsonerr.EchoNotFoundError(err).Echo(c)
@@ -210,3 +331,18 @@ func (m *mux) unobserve(c echo.Context) error {
 
 	return c.NoContent(204)
 }
+
+func toLocationDetails(l *users.Location) *locationDetails {
+	if l == nil {
+		return nil
+	}
+
+	return &locationDetails{
+		Longitude:  l.Longitude,
+		Latitude:   l.Latitude,
+		Altitude:   l.Altitude,
+		Bearing:    l.Bearing,
+		Accuracy:   l.Accuracy,
+		LastUpdate: l.LastUpdate,
+	}
+}
@@ -0,0 +1,131 @@
+package me
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+)
+
+var ErrPasswordNotSet = errors.New("the account has no password, set it with the password reset first")
+
+// changePassword
+//
+// @summary change password
+// @description changes user password, all other sessions are logged out.
+// @description Returns a new pair of tokens for the current session.
+// @description Accounts created with OIDC have no password, they set it with the password reset.
+// @tags me
+// @accept json
+// @produce json
+// @param password body changePasswordRequest true "current and new password"
+// @success 200 {object} tokensResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 403 {object} jsonerr.JSONError "forbidden (invalid current password or the account has no password)"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/password [PUT]
+func (m *mux) changePassword(c echo.Context) error {
+	request, bindErr := binder.BindRequest[changePasswordRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	tokenData := request.TokenData()
+	currentSession, err := id.FromString(tokenData.SessionID)
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	if err := m.verifyPassword(user, request.Request.CurrentPassword); err != nil {
+		return err.Echo(c)
+	}
+
+	encPass, err := m.hasher.Hash(request.Request.NewPassword)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	if err := m.userAdapter.UpdatePassword(request.Context(), user.ID, encPass); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	// log out all other devices
+	for _, s := range user.Auth.Sessions {
+		if s.ID == currentSession {
+			continue
+		}
+		if err := m.revokedTokens.RevokeSession(request.Context(), s.ID); err != nil {
+			return jsonerr.EchoInternalError(err).Echo(c)
+		}
+	}
+	if err := m.userAdapter.DeleteSessions(request.Context(), user.ID, currentSession); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	// the current session gets fresh tokens, the old access token is not valid anymore
+	token, refresh, err := m.jwt.GenerateTokens(user.Auth.Username, user.ID, currentSession, request.Scopes())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if err := m.userAdapter.UpdateTokens(request.Context(), user.ID, currentSession, &token, &refresh); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if err := m.revokedTokens.RevokeToken(request.Context(), tokenData.Id, time.Unix(tokenData.ExpiresAt, 0)); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, tokensResponse{
+		ID:           user.ID.Hex(),
+		SessionID:    currentSession.Hex(),
+		Token:        token,
+		RefreshToken: refresh,
+	})
+}
+
+// updateEmail
+//
+// @summary update email
+// @description updates logged user email, used for password reset
+// @tags me
+// @accept json
+// @param email body updateEmailRequest true "update email object"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/email [PUT]
+func (m *mux) updateEmail(c echo.Context) error {
+	request, bindErr := binder.BindRequest[updateEmailRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	if err := m.userAdapter.UpdateEmail(request.Context(), request.UserID(), request.Request.Email); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// verifyPassword returns forbidden error if the password doesn't match the user password.
+// Users without a password (created with OIDC) get ErrPasswordNotSet, so the client can offer the password reset.
+func (m *mux) verifyPassword(user users.User, password string) *jsonerr.JSONError {
+	if user.Auth.Password == "" {
+		return jsonerr.EchoError(http.StatusForbidden, "forbidden", ErrPasswordNotSet)
+	}
+	if err := m.hasher.Verify(user.Auth.Password, password); err != nil {
+		return jsonerr.EchoForbiddenError()
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package me
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+)
+
+// setPrecision
+//
+// @summary set location precision
+// @description sets the precision of the location shared with the user (friend or group member):
+// @description exact, about 1 km, about 10 km or status only. Approximate locations are snapped to a fixed grid.
+// @tags me
+// @accept json
+// @param precision body setPrecisionRequest true "precision"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "requested user not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/precision [PUT]
+func (m *mux) setPrecision(c echo.Context) error {
+	request, bindErr := binder.BindRequest[setPrecisionRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	viewer, err := m.lookupUser(request.Context(), request.UserID(), request.Request.Username)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	precision := users.Precision(request.Request.Precision)
+	if err := m.userAdapter.SetLocationPrecision(request.Context(), request.UserID(), viewer.ID, precision); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
@@ -0,0 +1,98 @@
+package me
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+)
+
+const (
+	// relationshipMutual users observe each other and see each other's details
+	relationshipMutual = "mutual"
+	// relationshipFollowing only the requester observes the user
+	relationshipFollowing = "following"
+	// relationshipFollower only the user observes the requester
+	relationshipFollower = "follower"
+)
+
+// getRelationships
+//
+// @summary get relationships
+// @description returns users only observed by the requester (following), users only observing the requester (followers)
+// @description and users observing each other with the requester (mutual), each user is in one of the lists.
+// @description Only mutual users see each other's details.
+// @tags me
+// @produce json
+// @success 200 {object} relationshipsResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/relationships [GET]
+func (m *mux) getRelationships(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	observed, err := m.userAdapter.GetUsers(request.Context(), user.SubscribedUsers)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	observers, err := m.userAdapter.GetObservers(request.Context(), user.ID)
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	result := relationshipsResponse{
+		Following: make([]relationshipDetails, 0, len(observed)),
+		Followers: make([]relationshipDetails, 0, len(observers)),
+		Mutual:    make([]relationshipDetails, 0),
+	}
+	for _, u := range observed {
+		since := user.ObservesSince(u.ID)
+		if !u.SubscribeUser(user.ID) {
+			result.Following = append(result.Following, toRelationshipDetails(user, u, relationshipFollowing, since))
+			continue
+		}
+
+		since = later(since, u.ObservesSince(user.ID))
+		result.Mutual = append(result.Mutual, toRelationshipDetails(user, u, relationshipMutual, since))
+	}
+	// mutual observers are already listed
+	for _, u := range observers {
+		if !user.SubscribeUser(u.ID) {
+			since := u.ObservesSince(user.ID)
+			result.Followers = append(result.Followers, toRelationshipDetails(user, u, relationshipFollower, since))
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func toRelationshipDetails(user, other users.User, state string, since *time.Time) relationshipDetails {
+	return relationshipDetails{
+		Username:  other.Auth.Username,
+		Precision: string(user.PrecisionFor(other.ID)),
+		State:     state,
+		Since:     since,
+	}
+}
+
+// later returns the later time, nil if any of them is unknown
+func later(a, b *time.Time) *time.Time {
+	if a == nil || b == nil {
+		return nil
+	}
+	if a.After(*b) {
+		return a
+	}
+	return b
+}
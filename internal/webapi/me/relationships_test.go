@@ -0,0 +1,55 @@
+package me
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/internal/webapitest"
+	"whereiseveryone/pkg/id"
+)
+
+func Test_GetRelationships(t *testing.T) {
+	alice := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "alice"}}
+	bob := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "bob"}, SubscribedUsers: []id.ID{alice.ID}}
+	carol := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "carol"}}
+	dave := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "dave"}, SubscribedUsers: []id.ID{alice.ID}}
+	alice.SubscribedUsers = []id.ID{bob.ID, carol.ID}
+
+	e := webapitest.NewEcho()
+	mux := NewMux(webapitest.NewUsers(alice, bob, carol, dave), nil, nil, nil, nil, nil, nil, nil,
+		&webapitest.Timer{Time: time.Now()}, nil, Config{})
+	mux.Route(e.Group("/me", webapitest.Auth), webapitest.Auth)
+
+	rec := webapitest.Request(e, http.MethodGet, "/me/relationships", "", alice.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get relationships: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var resp relationshipsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	// each user is listed once, mutual friends are neither following nor followers
+	usernames := func(details []relationshipDetails, state string) []string {
+		names := make([]string, 0, len(details))
+		for _, d := range details {
+			if d.State != state {
+				t.Errorf("%s: state %q, want %q", d.Username, d.State, state)
+			}
+			names = append(names, d.Username)
+		}
+		return names
+	}
+	if got := usernames(resp.Mutual, relationshipMutual); len(got) != 1 || got[0] != "bob" {
+		t.Errorf("mutual: %v, want [bob]", got)
+	}
+	if got := usernames(resp.Following, relationshipFollowing); len(got) != 1 || got[0] != "carol" {
+		t.Errorf("following: %v, want [carol]", got)
+	}
+	if got := usernames(resp.Followers, relationshipFollower); len(got) != 1 || got[0] != "dave" {
+		t.Errorf("followers: %v, want [dave]", got)
+	}
+}
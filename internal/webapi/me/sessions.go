@@ -0,0 +1,91 @@
+package me
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+)
+
+// getSessions
+//
+// @summary get sessions
+// @description returns all logged-in devices of the user
+// @tags me
+// @produce json
+// @success 200 {object} getSessionsResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/sessions [GET]
+func (m *mux) getSessions(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	currentSession := request.TokenData().SessionID
+	result := make(getSessionsResponse, 0, len(user.Auth.Sessions))
+	for _, s := range user.Auth.Sessions {
+		result = append(result, toSessionDetails(s, currentSession))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func toSessionDetails(s users.Session, currentSession string) sessionDetails {
+	return sessionDetails{
+		ID:         s.ID.Hex(),
+		DeviceName: s.DeviceName,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		Current:    s.ID.Hex() == currentSession,
+	}
+}
+
+// deleteSession
+//
+// @summary delete session
+// @description logs out the device, all tokens issued for the session are revoked
+// @tags me
+// @param id path string true "session id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "session not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/sessions/{id} [DELETE]
+func (m *mux) deleteSession(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	sessionID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	err = m.userAdapter.DeleteSession(request.Context(), request.UserID(), sessionID)
+	if err != nil {
+		if errors.Is(err, users.ErrSessionNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	if err := m.revokedTokens.RevokeSession(request.Context(), sessionID); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
@@ -0,0 +1,178 @@
+package me
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/shares"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/id"
+)
+
+var (
+	ErrCannotShareWithSelf = errors.New("cannot share location with yourself")
+	ErrInvalidShareExpiry  = errors.New("invalid share expiry")
+)
+
+// createShare
+//
+// @summary share location
+// @description shares the location with the user until the given time, without becoming friends
+// @description (e.g. a marketplace pickup). The user sees the location in /me/friends, the sharing stops automatically.
+// @description An existing share with the user is replaced.
+// @tags me
+// @accept json
+// @produce json
+// @param share body createShareRequest true "share"
+// @success 201 {object} shareDetails
+// @failure 400 {object} jsonerr.JSONError "invalid request (e.g. expiry in the past or too far)"
+// @failure 404 {object} jsonerr.JSONError "requested user not exists"
+// @failure 409 {object} jsonerr.JSONError "the user is blocked"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/shares [POST]
+func (m *mux) createShare(c echo.Context) error {
+	request, bindErr := binder.BindRequest[createShareRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	now := m.timer.Now()
+	expiresAt := request.Request.ExpiresAt.UTC()
+	if !expiresAt.After(now) || expiresAt.Sub(now) > m.config.MaxShareValidity {
+		err := fmt.Errorf("%w: must be in the future and at most %s from now",
+			ErrInvalidShareExpiry, m.config.MaxShareValidity)
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	viewer, err := m.lookupUser(request.Context(), request.UserID(), request.Request.Username)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if viewer.ID == request.UserID() {
+		return jsonerr.EchoInvalidRequestError(ErrCannotShareWithSelf).Echo(c)
+	}
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if user.Blocks(viewer.ID) {
+		return jsonerr.EchoConflictError(ErrUserBlocked).Echo(c)
+	}
+
+	share, err := m.shares.Create(request.Context(), shares.Share{
+		From:      request.UserID(),
+		To:        viewer.ID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusCreated, toShareDetails(share, viewer.Auth.Username))
+}
+
+// getShares
+//
+// @summary get location shares
+// @description returns active time-limited location shares of the user
+// @tags me
+// @produce json
+// @success 200 {object} getSharesResponse
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/shares [GET]
+func (m *mux) getShares(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	result, err := m.sharesDetails(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// deleteShare
+//
+// @summary stop location share
+// @description stops the time-limited location share before it expires
+// @tags me
+// @param id path string true "share id"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request"
+// @failure 404 {object} jsonerr.JSONError "share not exists"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/shares/{id} [DELETE]
+func (m *mux) deleteShare(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	shareID, err := id.FromString(c.Param("id"))
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	if err := m.shares.Delete(request.Context(), shareID, request.UserID()); err != nil {
+		if errors.Is(err, shares.ErrShareNotExists) {
+			return jsonerr.EchoNotFoundError(err).Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
+
+// sharesDetails returns active shares of the user
+func (m *mux) sharesDetails(ctx context.Context, userID id.ID) (getSharesResponse, error) {
+	sharedBy, err := m.shares.SharedBy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewerIDs := make([]id.ID, 0, len(sharedBy))
+	for _, s := range sharedBy {
+		viewerIDs = append(viewerIDs, s.To)
+	}
+	viewers, err := m.userAdapter.GetUsers(ctx, viewerIDs)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[id.ID]string, len(viewers))
+	for _, u := range viewers {
+		usernames[u.ID] = u.Auth.Username
+	}
+
+	result := make(getSharesResponse, 0, len(sharedBy))
+	for _, s := range sharedBy {
+		if username, ok := usernames[s.To]; ok {
+			result = append(result, toShareDetails(s, username))
+		}
+	}
+
+	return result, nil
+}
+
+func toShareDetails(s shares.Share, username string) shareDetails {
+	return shareDetails{
+		ID:        s.ID.Hex(),
+		Username:  username,
+		CreatedAt: s.CreatedAt,
+		ExpiresAt: s.ExpiresAt,
+	}
+}
@@ -0,0 +1,168 @@
+package me
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/pointers"
+	"whereiseveryone/pkg/totp"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication enrollment is not started")
+)
+
+// enrollTwoFactor
+//
+// @summary start 2FA enrollment
+// @description generates a new TOTP secret, 2FA is enabled after the first code is verified (see /me/2fa/verify).
+// @description Calling it again before the verification replaces the secret.
+// @tags me
+// @produce json
+// @success 200 {object} enrollTwoFactorResponse
+// @failure 409 {object} jsonerr.JSONError "2FA is already enabled"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/2fa [POST]
+func (m *mux) enrollTwoFactor(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if user.Auth.TwoFactor.IsEnabled() {
+		return jsonerr.EchoConflictError(ErrTwoFactorAlreadyEnabled).Echo(c)
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	err = m.userAdapter.SetTwoFactor(request.Context(), user.ID, &users.TwoFactor{
+		Secret:        secret,
+		Enabled:       false,
+		RecoveryCodes: []string{},
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, enrollTwoFactorResponse{
+		Secret: secret,
+		URI:    m.otp.ProvisioningURI(m.config.TOTPIssuer, user.Auth.Username, secret),
+	})
+}
+
+// verifyTwoFactor
+//
+// @summary enable 2FA
+// @description verifies the first TOTP code and enables 2FA, returns one-time recovery codes (shown only once)
+// @tags me
+// @accept json
+// @produce json
+// @param code body verifyTwoFactorRequest true "TOTP code"
+// @success 200 {object} recoveryCodesResponse
+// @failure 400 {object} jsonerr.JSONError "invalid request (invalid code or enrollment not started)"
+// @failure 409 {object} jsonerr.JSONError "2FA is already enabled"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/2fa/verify [POST]
+func (m *mux) verifyTwoFactor(c echo.Context) error {
+	request, bindErr := binder.BindRequest[verifyTwoFactorRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	twoFactor := user.Auth.TwoFactor
+	if twoFactor == nil {
+		return jsonerr.EchoInvalidRequestError(ErrTwoFactorNotEnrolled).Echo(c)
+	}
+	if twoFactor.Enabled {
+		return jsonerr.EchoConflictError(ErrTwoFactorAlreadyEnabled).Echo(c)
+	}
+
+	now := m.timer.Now()
+	step, err := m.otp.Validate(twoFactor.Secret, request.Request.Code, now)
+	if err != nil {
+		return jsonerr.EchoInvalidRequestError(err).Echo(c)
+	}
+
+	codes, hashes, err := users.NewRecoveryCodes()
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	err = m.userAdapter.SetTwoFactor(request.Context(), user.ID, &users.TwoFactor{
+		Secret:        twoFactor.Secret,
+		Enabled:       true,
+		LastStep:      step,
+		RecoveryCodes: hashes,
+		EnabledAt:     pointers.Pointer(now),
+	})
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// disableTwoFactor
+//
+// @summary disable 2FA
+// @description disables 2FA, requires the password and TOTP code (or one of recovery codes).
+// @description Accounts created with OIDC have no password, they set it with the password reset first.
+// @tags me
+// @accept json
+// @param confirmation body disableTwoFactorRequest true "password and code"
+// @success 204
+// @failure 400 {object} jsonerr.JSONError "invalid request (2FA is not enabled)"
+// @failure 403 {object} jsonerr.JSONError "forbidden (invalid password or code, or the account has no password)"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /me/2fa [DELETE]
+func (m *mux) disableTwoFactor(c echo.Context) error {
+	request, bindErr := binder.BindRequest[disableTwoFactorRequest](c, true)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	user, err := m.userAdapter.GetUser(request.Context(), request.UserID())
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+	if !user.Auth.TwoFactor.IsEnabled() {
+		return jsonerr.EchoInvalidRequestError(users.ErrTwoFactorNotEnabled).Echo(c)
+	}
+
+	if err := m.verifyPassword(user, request.Request.Password); err != nil {
+		return err.Echo(c)
+	}
+
+	err = users.VerifySecondFactor(request.Context(), m.userAdapter, m.otp, m.timer.Now(), user, request.Request.Code)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidSecondFactor) {
+			return jsonerr.EchoForbiddenError().Echo(c)
+		}
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	if err := m.userAdapter.SetTwoFactor(request.Context(), user.ID, nil); err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	return c.NoContent(204)
+}
@@ -9,9 +9,16 @@ type updateStatusRequest struct {
 type getFriendsResponse []friendDetails
 
 type friendDetails struct {
-	Username string          `json:"username"`
-	Status   string          `json:"status"`
-	Location locationDetails `json:"location"`
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	// Location is null if the user has no location or shares the status only
+	Location *locationDetails `json:"location"`
+	// Precision of the shared location
+	Precision string `json:"precision" enums:"exact,1km,10km,status_only"`
+	// Hidden is true if the user hides the location from everyone (ghost mode)
+	Hidden bool `json:"location_hidden"`
+	// SharedUntil in UTC time, set if the user is not a friend but shares the location for a limited time
+	SharedUntil *time.Time `json:"shared_until,omitempty"`
 }
 
 type locationDetails struct {
@@ -31,3 +38,277 @@ type updateLocationRequest struct {
 type observeRequest struct {
 	Username string `json:"username"`
 }
+
+type relationshipsResponse struct {
+	// Following are users observed by the user, who don't observe the user
+	Following []relationshipDetails `json:"following"`
+	// Followers are users observing the user, who aren't observed by the user
+	Followers []relationshipDetails `json:"followers"`
+	// Mutual are users observing each other with the user
+	Mutual []relationshipDetails `json:"mutual"`
+}
+
+type relationshipDetails struct {
+	Username string `json:"username"`
+	// Precision of the user location shared with the other user
+	Precision string `json:"precision" enums:"exact,1km,10km,status_only"`
+	// State is mutual, following (only the user observes) or follower (only the other user observes)
+	State string `json:"state" enums:"mutual,following,follower"`
+	// Since in UTC time, when the relationship was created (null for relationships created before it was tracked)
+	Since *time.Time `json:"since"`
+}
+
+type setPrecisionRequest struct {
+	// Username of the user to set the precision for
+	Username string `json:"username" validate:"required"`
+	// Precision of the location shared with the user
+	Precision string `json:"precision" validate:"required,oneof=exact 1km 10km status_only"`
+}
+
+type setGhostRequest struct {
+	// Mode is hidden (the location is not shown) or frozen (the current location is shown until the sharing is resumed)
+	Mode string `json:"mode" validate:"required,oneof=hidden frozen"`
+	// Until is a time when the sharing is resumed automatically (UTC time), null - until disabled
+	Until *time.Time `json:"until"`
+}
+
+type ghostResponse struct {
+	// Enabled is true if the location sharing is paused
+	Enabled bool   `json:"enabled"`
+	Mode    string `json:"mode,omitempty" enums:"hidden,frozen"`
+	// Since in UTC time
+	Since *time.Time `json:"since,omitempty"`
+	// Until in UTC time, null - until disabled
+	Until *time.Time `json:"until,omitempty"`
+}
+
+type createShareRequest struct {
+	// Username of the user to share the location with
+	Username string `json:"username" validate:"required"`
+	// ExpiresAt tells when the sharing stops (UTC time)
+	ExpiresAt time.Time `json:"expires_at" validate:"required"`
+}
+
+type getSharesResponse []shareDetails
+
+type shareDetails struct {
+	ID string `json:"id"`
+	// Username of the user the location is shared with
+	Username string `json:"username"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt in UTC time
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type createLinkRequest struct {
+	// Name of the link, e.g. "grandma"
+	Name string `json:"name" validate:"required,max=64"`
+	// ExpiresAt tells when the link stops working (UTC time)
+	ExpiresAt time.Time `json:"expires_at" validate:"required"`
+	// MaxViewers is a number of distinct viewers who can open the link
+	MaxViewers int `json:"max_viewers" validate:"required,min=1,max=100"`
+	// Precision of the shared location (exact by default)
+	Precision string `json:"precision" validate:"omitempty,oneof=exact 1km 10km status_only"`
+}
+
+type createLinkResponse struct {
+	linkDetails `json:",inline"`
+	// Token of the link, it's shown only once (the link is /api/public/share/{token})
+	Token string `json:"token"`
+}
+
+type getLinksResponse []linkDetails
+
+type linkDetails struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Precision string `json:"precision" enums:"exact,1km,10km,status_only"`
+	// MaxViewers is a number of distinct viewers who can open the link
+	MaxViewers int `json:"max_viewers"`
+	// Viewers is a number of distinct viewers who opened the link
+	Viewers int `json:"viewers"`
+	// Views is a number of times the link was opened
+	Views int `json:"views"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt in UTC time
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type friendRequestRequest struct {
+	// Username of the user to send the request to
+	Username string `json:"username" validate:"required"`
+}
+
+type getFriendRequestsResponse []friendRequestDetails
+
+type friendRequestDetails struct {
+	ID string `json:"id"`
+	// Username of the other user (sender of incoming or receiver of outgoing request)
+	Username string `json:"username"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt in UTC time
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type blockRequest struct {
+	// Username of the user to block or unblock
+	Username string `json:"username" validate:"required"`
+}
+
+type getBlocksResponse []blockedUserDetails
+
+type blockedUserDetails struct {
+	Username string `json:"username"`
+}
+
+type getSessionsResponse []sessionDetails
+
+type sessionDetails struct {
+	ID         string `json:"id"`
+	DeviceName string `json:"device_name"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt in UTC time, updated when the session tokens are refreshed
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Current is true for the session used for the request
+	Current bool `json:"current"`
+}
+
+// exportResponse contains all data stored about the user
+type exportResponse struct {
+	ID     string     `json:"id"`
+	Auth   exportAuth `json:"auth"`
+	Status string     `json:"status"`
+	// Location is the last user location (null if never updated)
+	Location *locationDetails `json:"location"`
+	// Observed are usernames of users observed by the user
+	Observed []string `json:"observed"`
+	// Observers are usernames of users observing the user
+	Observers []string `json:"observers"`
+	// Blocked are usernames of users blocked by the user
+	Blocked []string `json:"blocked"`
+	// Groups are names of groups the user is a member of
+	Groups []string `json:"groups"`
+	// Shares are active time-limited location shares
+	Shares []shareDetails `json:"shares"`
+	// Ghost is the location sharing pause state
+	Ghost ghostResponse `json:"ghost"`
+	// Links are active public share links (without tokens)
+	Links []linkDetails `json:"links"`
+	// Identities are linked external identities
+	Identities []identityDetails `json:"identities"`
+	// APIKeys are device API keys (without the keys themselves)
+	APIKeys []apiKeyDetails `json:"api_keys"`
+	// ExportedAt in UTC time
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// exportAuth is user auth data without secrets (password hash and tokens)
+type exportAuth struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	// TwoFactorEnabled tells if 2FA is enabled (the secret is not exported)
+	TwoFactorEnabled bool             `json:"two_factor_enabled"`
+	Sessions         []sessionDetails `json:"sessions"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+type enrollTwoFactorResponse struct {
+	// Secret is base32 encoded TOTP secret, for manual entry in authenticator app
+	Secret string `json:"secret"`
+	// URI is otpauth provisioning URI, to be shown as QR code
+	URI string `json:"uri"`
+}
+
+type verifyTwoFactorRequest struct {
+	// Code is TOTP code generated by authenticator app
+	Code string `json:"code" validate:"required"`
+}
+
+type recoveryCodesResponse struct {
+	// RecoveryCodes are one-time codes which can be used instead of TOTP code, shown only once
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type disableTwoFactorRequest struct {
+	// Password user password
+	Password string `json:"password" validate:"required"`
+	// Code is TOTP code or one of recovery codes
+	Code string `json:"code" validate:"required"`
+}
+
+type getIdentitiesResponse []identityDetails
+
+type identityDetails struct {
+	// Provider is a name of OIDC provider
+	Provider string `json:"provider"`
+	// Subject is the user ID in the provider
+	Subject string `json:"subject"`
+	// Email is the user email in the provider (can be empty)
+	Email string `json:"email"`
+	// LinkedAt in UTC time
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+type unlinkIdentityRequest struct {
+	// Provider is a name of OIDC provider
+	Provider string `param:"provider" validate:"required"`
+}
+
+type createAPIKeyRequest struct {
+	// Name of the key (device), e.g. "bike tracker"
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type createAPIKeyResponse struct {
+	apiKeyDetails `json:",inline"`
+	// Key is the API key, it's shown only once (send it in X-Api-Key header)
+	Key string `json:"key"`
+}
+
+type getAPIKeysResponse []apiKeyDetails
+
+type apiKeyDetails struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Hint is the beginning of the key
+	Hint string `json:"hint"`
+	// CreatedAt in UTC time
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt in UTC time (null if never used)
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type deleteAccountRequest struct {
+	// Password user password, required to confirm the deletion
+	Password string `json:"password" validate:"required"`
+}
+
+type updateEmailRequest struct {
+	// Email used for password reset
+	Email string `json:"email" validate:"required,email"`
+}
+
+type changePasswordRequest struct {
+	// CurrentPassword user password
+	CurrentPassword string `json:"current_password" validate:"required"`
+	// NewPassword new user password, min 8 characters
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+type tokensResponse struct {
+	// ID is user id (uuid)
+	ID string `json:"id"`
+	// SessionID is an ID of the current session
+	SessionID string `json:"session_id"`
+	// Token user auth token (Bearer)
+	Token string `json:"token"`
+	// RefreshToken user refresh token
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,152 @@
+package public
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+	"whereiseveryone/internal/links"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/binder"
+	"whereiseveryone/internal/webapi/jsonerr"
+	"whereiseveryone/pkg/crypto"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
+	"whereiseveryone/pkg/timer"
+)
+
+const (
+	// viewerCookie identifies the viewer of the share link, it's issued on the first open
+	viewerCookie = "share_viewer"
+	// viewerTokenLength is a length of random bytes of the viewer cookie
+	viewerTokenLength = 32
+)
+
+var ErrInvalidLink = errors.New("invalid, expired or revoked share link")
+
+type mux struct {
+	links       links.Adapter
+	userAdapter users.Adapter
+	timer       timer.Timer
+	jwt         *jwt.JWT
+}
+
+func NewMux(links links.Adapter, userAdapter users.Adapter, timer timer.Timer, jwt *jwt.JWT) *mux {
+	return &mux{
+		links:       links,
+		userAdapter: userAdapter,
+		timer:       timer,
+		jwt:         jwt,
+	}
+}
+
+func (m *mux) Route(g *echo.Group, _ echo.MiddlewareFunc) {
+	g.GET("/share/:token", m.getShare)
+}
+
+// getShare
+//
+// @summary get shared location
+// @description returns the live location and status of the user who created the share link, no account is required.
+// @description The link can be opened by a limited number of distinct viewers identified by `share_viewer` cookie.
+// @description Requests without the cookie get it with a redirect to the same URL, they are not counted as viewers.
+// @tags public
+// @produce json
+// @param token path string true "share link token"
+// @success 200 {object} shareResponse
+// @success 302 "the viewer cookie is set, the request has to be repeated with it"
+// @failure 403 {object} jsonerr.JSONError "the link reached the maximum number of viewers"
+// @failure 404 {object} jsonerr.JSONError "invalid, expired or revoked link"
+// @failure 500 {object} jsonerr.JSONError "internal server error"
+// @router /public/share/{token} [GET]
+func (m *mux) getShare(c echo.Context) error {
+	request, bindErr := binder.BindRequest[binder.EmptyBody](c, false)
+	if bindErr != nil {
+		return bindErr.Echo(c)
+	}
+	defer request.Cancel()
+
+	claims, err := m.jwt.ValidateShareLinkToken(c.Param("token"))
+	if err != nil {
+		return jsonerr.EchoNotFoundError(ErrInvalidLink).Echo(c)
+	}
+	userID, err := id.FromString(claims.Subject)
+	if err != nil {
+		return jsonerr.EchoNotFoundError(ErrInvalidLink).Echo(c)
+	}
+	linkID, err := id.FromString(claims.SessionID)
+	if err != nil {
+		return jsonerr.EchoNotFoundError(ErrInvalidLink).Echo(c)
+	}
+
+	// requests without the cookie (link previews, scripts) are not counted, browsers repeat them with the cookie
+	cookie, err := c.Cookie(viewerCookie)
+	if err != nil || cookie.Value == "" {
+		if err := m.issueViewerCookie(c, time.Unix(claims.ExpiresAt, 0)); err != nil {
+			return jsonerr.EchoInternalError(err).Echo(c)
+		}
+		return c.Redirect(http.StatusFound, c.Request().URL.Path)
+	}
+	viewer := crypto.HashToken(cookie.Value)
+
+	link, err := m.links.View(request.Context(), userID, linkID, viewer)
+	if errors.Is(err, links.ErrLinkNotExists) {
+		return jsonerr.EchoNotFoundError(ErrInvalidLink).Echo(c)
+	}
+	if errors.Is(err, links.ErrTooManyViewers) {
+		return jsonerr.EchoError(http.StatusForbidden, "forbidden", err).Echo(c)
+	}
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	user, err := m.userAdapter.GetUser(request.Context(), link.UserID)
+	if errors.Is(err, users.ErrUserNotExists) {
+		return jsonerr.EchoNotFoundError(ErrInvalidLink).Echo(c)
+	}
+	if err != nil {
+		return jsonerr.EchoInternalError(err).Echo(c)
+	}
+
+	now := m.timer.Now()
+	result := shareResponse{
+		Username:  user.Auth.Username,
+		Status:    user.Status,
+		Precision: link.Precision,
+		Hidden:    user.LocationHidden(now),
+		ExpiresAt: link.ExpiresAt,
+	}
+	if l := user.LocationWithPrecision(users.Precision(link.Precision), now); l != nil {
+		result.Location = &locationDetails{
+			Longitude:  l.Longitude,
+			Latitude:   l.Latitude,
+			Altitude:   l.Altitude,
+			Bearing:    l.Bearing,
+			Accuracy:   l.Accuracy,
+			LastUpdate: l.LastUpdate,
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// issueViewerCookie sets a new viewer cookie, the viewer key is its hash.
+// The cookie is scoped to the link and expires with it.
+func (m *mux) issueViewerCookie(c echo.Context, expiresAt time.Time) error {
+	token, err := crypto.RandomToken(viewerTokenLength)
+	if err != nil {
+		return fmt.Errorf("generate viewer token: %w", err)
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     viewerCookie,
+		Value:    token,
+		Path:     c.Request().URL.Path,
+		Expires:  expiresAt,
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package public
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"whereiseveryone/internal/links"
+	"whereiseveryone/internal/users"
+	"whereiseveryone/internal/webapi/internal/webapitest"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/jwt"
+)
+
+func Test_GetShare_Viewers(t *testing.T) {
+	tm := &webapitest.Timer{Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	j := jwt.NewJWT(tm, jwt.NewHMACKeySet([]byte("secret")), jwt.Config{})
+
+	user := &users.User{ID: id.NewID(), Auth: users.Auth{Username: "alice"}, Status: "home"}
+	link := &links.Link{
+		ID:        id.NewID(),
+		UserID:    user.ID,
+		Precision: string(users.PrecisionExact),
+		Viewers:   []string{},
+		ExpiresAt: tm.Time.Add(time.Hour),
+	}
+	linksAdapter := webapitest.NewLinks(link)
+
+	e := webapitest.NewEcho()
+	NewMux(linksAdapter, webapitest.NewUsers(user), tm, j).Route(e.Group("/public"), nil)
+
+	open := func(token string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/public/share/"+token, nil)
+		// headers are client controlled, they must not identify the viewer
+		req.Header.Set(echo.HeaderXForwardedFor, id.NewID().Hex())
+		req.Header.Set("User-Agent", id.NewID().Hex())
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	token, err := j.GenerateShareLinkToken(user.ID, link.ID, link.ExpiresAt)
+	if err != nil {
+		t.Fatalf("generate share link token: %v", err)
+	}
+
+	// a request without the cookie gets it, but it isn't counted (e.g. link preview of a chat app)
+	rec := open(token)
+	if rec.Code != http.StatusFound || rec.Header().Get(echo.HeaderLocation) != "/public/share/"+token {
+		t.Fatalf("first open: status %d, location: %q", rec.Code, rec.Header().Get(echo.HeaderLocation))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != viewerCookie || !cookies[0].HttpOnly {
+		t.Fatalf("viewer cookie should be issued, is: %+v", cookies)
+	}
+	if len(link.Viewers) != 0 || link.Views != 0 {
+		t.Fatalf("request without cookie counted, viewers: %d, views: %d", len(link.Viewers), link.Views)
+	}
+
+	// the viewer is recognized by the cookie, even from another address
+	for range 2 {
+		if rec := open(token, cookies[0]); rec.Code != http.StatusOK {
+			t.Fatalf("open with cookie: status %d, body: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if len(link.Viewers) != 1 || link.Views != 2 {
+		t.Fatalf("unexpected viewers: %d, views: %d", len(link.Viewers), link.Views)
+	}
+
+	// a full link rejects new viewers, known ones can still open it
+	linksAdapter.Full = true
+	newViewer := open(token).Result().Cookies()[0]
+	if rec := open(token, newViewer); rec.Code != http.StatusForbidden {
+		t.Fatalf("new viewer: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if rec := open(token, cookies[0]); rec.Code != http.StatusOK {
+		t.Fatalf("known viewer: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	// a token of another user doesn't match the link
+	forged, err := j.GenerateShareLinkToken(id.NewID(), link.ID, link.ExpiresAt)
+	if err != nil {
+		t.Fatalf("generate share link token: %v", err)
+	}
+	if rec := open(forged, cookies[0]); rec.Code != http.StatusNotFound {
+		t.Fatalf("token of another user: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := open("invalid"); rec.Code != http.StatusNotFound {
+		t.Fatalf("invalid token: status %d, body: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,26 @@
+package public
+
+import "time"
+
+type shareResponse struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	// Location is null if the user has no location, shares the status only or hides the location (ghost mode)
+	Location *locationDetails `json:"location"`
+	// Precision of the shared location
+	Precision string `json:"precision" enums:"exact,1km,10km,status_only"`
+	// Hidden is true if the user hides the location from everyone (ghost mode)
+	Hidden bool `json:"location_hidden"`
+	// ExpiresAt in UTC time, the link doesn't work after it
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type locationDetails struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+	Altitude  float64 `json:"altitude,omitempty"`
+	Bearing   float64 `json:"bearing,omitempty"`
+	Accuracy  float64 `json:"accuracy,omitempty"`
+	// LastUpdate in UTC time
+	LastUpdate time.Time `json:"last_update"`
+}
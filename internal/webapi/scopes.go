@@ -0,0 +1,51 @@
+package webapi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"whereiseveryone/internal/webapi/jsonerr"
+)
+
+// Scopes of access tokens, each route declares scopes it requires (see RequireScopes)
+const (
+	ScopeLocationWrite = "location:write"
+	ScopeFriendsRead   = "friends:read"
+	ScopeFriendsWrite  = "friends:write"
+	ScopeProfileRead   = "profile:read"
+	ScopeProfileWrite  = "profile:write"
+)
+
+// AllScopes are granted to users logging in (unless they request some of them only)
+var AllScopes = []string{ //nolint:gochecknoglobals // cannot be const
+	ScopeLocationWrite,
+	ScopeFriendsRead,
+	ScopeFriendsWrite,
+	ScopeProfileRead,
+	ScopeProfileWrite,
+}
+
+var ErrMissingScope = errors.New("missing scope")
+
+// RequireScopes returns middleware rejecting requests with tokens without all the scopes (403).
+// It must be used after authMiddleware.
+func RequireScopes(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, err := GetJWTToken(c)
+			if err != nil {
+				return jsonerr.EchoForbiddenError().Echo(c)
+			}
+
+			for _, scope := range scopes {
+				if !slices.Contains(token.Scopes, scope) {
+					return jsonerr.EchoError(403, "forbidden", fmt.Errorf("%w: %s", ErrMissingScope, scope)).Echo(c)
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -2,22 +2,25 @@ package crypto
 
 import (
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// HashPassword hashes the password with bcrypt (cost 14), use Hasher for configurable hashing
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	if err != nil {
-		return "", fmt.Errorf("encrypt the password: %w", err)
-	}
-
-	return string(bytes), nil
+	return NewBcryptHasher(14).Hash(password)
 }
 
 // VerifyPassword compares user password with the encrypted one
+// The encrypted password can be created with any supported algorithm (see Hasher)
 func VerifyPassword(userPassword string, providedPassword string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(userPassword), []byte(providedPassword))
+	var err error
+	if strings.HasPrefix(userPassword, "$"+string(AlgorithmArgon2id)+"$") {
+		err = verifyArgon2id(userPassword, providedPassword)
+	} else {
+		err = bcrypt.CompareHashAndPassword([]byte(userPassword), []byte(providedPassword))
+	}
 
 	if err != nil {
 		return fmt.Errorf("incorrect password: %w", err)
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +52,84 @@ func Test_HashAndVerify(t *testing.T) {
 		})
 	}
 }
+
+func Test_Argon2id_HashAndVerify(t *testing.T) {
+	hasher := NewArgon2idHasher(Argon2idParams{Memory: 1024, Time: 1, Threads: 1})
+
+	hash, err := hasher.Hash("pass_1")
+	if err != nil {
+		t.Fatalf("hash the password: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash format: %s", hash)
+	}
+
+	if err := VerifyPassword(hash, "pass_1"); err != nil {
+		t.Fatalf("cannot verify the password: %v", err)
+	}
+	if err := VerifyPassword(hash, "pass_2"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Fatalf("invalid password verified, err: %v", err)
+	}
+}
+
+func Test_NeedsRehash(t *testing.T) {
+	bcryptHash := "$2a$14$Pxc9Eyl3bKxyMAvvetH/iujpX3gzCrSUyr1ux7u6yRZiRQsQmrgxO"
+	params := Argon2idParams{Memory: 1024, Time: 1, Threads: 1}
+	argon2idHash, err := NewArgon2idHasher(params).Hash("pass_1")
+	if err != nil {
+		t.Fatalf("hash the password: %v", err)
+	}
+
+	type tc struct {
+		name   string
+		hasher *Hasher
+		hash   string
+		rehash bool
+	}
+
+	tcs := []tc{
+		{name: "bcrypt same cost", hasher: NewBcryptHasher(14), hash: bcryptHash, rehash: false},
+		{name: "bcrypt other cost", hasher: NewBcryptHasher(10), hash: bcryptHash, rehash: true},
+		{name: "bcrypt to argon2id", hasher: NewArgon2idHasher(params), hash: bcryptHash, rehash: true},
+		{name: "argon2id same params", hasher: NewArgon2idHasher(params), hash: argon2idHash, rehash: false},
+		{name: "argon2id other params", hasher: NewArgon2idHasher(Argon2idParams{Memory: 2048, Time: 1, Threads: 1}), hash: argon2idHash, rehash: true},
+		{name: "argon2id to bcrypt", hasher: NewBcryptHasher(14), hash: argon2idHash, rehash: true},
+	}
+
+	for _, test := range tcs {
+		t.Run(test.name, func(t *testing.T) {
+			if rehash := test.hasher.NeedsRehash(test.hash); rehash != test.rehash {
+				t.Fatalf("needs rehash is: %v, should be: %v", rehash, test.rehash)
+			}
+		})
+	}
+}
+
+func Test_HasherValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		valid  bool
+	}{
+		{name: "bcrypt", hasher: NewBcryptHasher(12), valid: true},
+		{name: "bcrypt too low cost", hasher: NewBcryptHasher(2), valid: false},
+		{name: "bcrypt too high cost", hasher: NewBcryptHasher(32), valid: false},
+		{name: "argon2id", hasher: NewArgon2idHasher(Argon2idParams{Memory: 19456, Time: 2, Threads: 1}), valid: true},
+		{name: "argon2id zero time", hasher: NewArgon2idHasher(Argon2idParams{Memory: 19456, Time: 0, Threads: 1})},
+		{name: "argon2id zero threads", hasher: NewArgon2idHasher(Argon2idParams{Memory: 19456, Time: 2, Threads: 0})},
+		{name: "argon2id too low memory", hasher: NewArgon2idHasher(Argon2idParams{Memory: 15, Time: 2, Threads: 2})},
+		{name: "unknown algorithm", hasher: &Hasher{algorithm: "md5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hasher.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidParams) {
+				t.Fatalf("invalid params accepted, err: %v", err)
+			}
+		})
+	}
+}
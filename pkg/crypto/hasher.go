@@ -0,0 +1,173 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithm is a password hashing algorithm
+type Algorithm string
+
+const (
+	AlgorithmBcrypt   Algorithm = "bcrypt"
+	AlgorithmArgon2id Algorithm = "argon2id"
+)
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+	// argon2idMinMemoryPerThread is a minimal memory (KiB) per thread required by argon2
+	argon2idMinMemoryPerThread = 8
+)
+
+var (
+	ErrIncorrectPassword = errors.New("incorrect password")
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+	ErrInvalidParams     = errors.New("invalid hashing parameters")
+)
+
+// Argon2idParams are argon2id cost parameters
+type Argon2idParams struct {
+	// Memory in KiB
+	Memory uint32
+	// Time is a number of iterations
+	Time uint32
+	// Threads is a degree of parallelism
+	Threads uint8
+}
+
+// Hasher hashes passwords using configured algorithm.
+// Hashes are self-describing (bcrypt or PHC string format for argon2id),
+// so passwords hashed with any supported algorithm can be verified.
+type Hasher struct {
+	algorithm  Algorithm
+	bcryptCost int
+	argon2id   Argon2idParams
+}
+
+func NewBcryptHasher(cost int) *Hasher {
+	return &Hasher{algorithm: AlgorithmBcrypt, bcryptCost: cost}
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Hasher {
+	return &Hasher{algorithm: AlgorithmArgon2id, argon2id: params}
+}
+
+// Validate checks the configured parameters can be used for hashing (argon2 panics on invalid ones)
+func (h *Hasher) Validate() error {
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("%w: bcrypt cost must be between %d and %d", ErrInvalidParams, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		p := h.argon2id
+		if p.Time < 1 || p.Threads < 1 {
+			return fmt.Errorf("%w: argon2id time and threads must be at least 1", ErrInvalidParams)
+		}
+		if p.Memory < argon2idMinMemoryPerThread*uint32(p.Threads) {
+			return fmt.Errorf("%w: argon2id memory must be at least %d KiB per thread",
+				ErrInvalidParams, argon2idMinMemoryPerThread)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported algorithm: %s", ErrInvalidParams, h.algorithm)
+	}
+
+	return nil
+}
+
+// Hash returns a self-describing hash of the password
+func (h *Hasher) Hash(password string) (string, error) {
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("encrypt the password: %w", err)
+		}
+		return string(bytes), nil
+	case AlgorithmArgon2id:
+		salt := make([]byte, argon2idSaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("generate salt: %w", err)
+		}
+		p := h.argon2id
+		key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, argon2idKeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, p.Memory, p.Time, p.Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %s", h.algorithm)
+	}
+}
+
+// Verify compares the password with the hash created by any supported algorithm
+func (h *Hasher) Verify(hash, password string) error {
+	return VerifyPassword(hash, password)
+}
+
+// NeedsRehash tells if the hash was created with a different algorithm or parameters than configured
+func (h *Hasher) NeedsRehash(hash string) bool {
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.bcryptCost
+	case AlgorithmArgon2id:
+		params, _, key, err := decodeArgon2id(hash)
+		return err != nil || params != h.argon2id || len(key) != argon2idKeyLength
+	default:
+		return false
+	}
+}
+
+func verifyArgon2id(hash, password string) error {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	//nolint:gosec // key length is validated by decodeArgon2id
+	computed := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return ErrIncorrectPassword
+	}
+
+	return nil
+}
+
+// decodeArgon2id parses `$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>`
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != string(AlgorithmArgon2id) {
+		return Argon2idParams{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("unsupported argon2 version: %s", parts[2])
+	}
+
+	var p Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("parse argon2 params: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("decode salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 || len(key) > 1024 {
+		return Argon2idParams{}, nil, nil, errors.New("invalid argon2 key")
+	}
+
+	return p, salt, key, nil
+}
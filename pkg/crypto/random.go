@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+// codeAlphabet is used for codes typed by users, ambiguous characters (0/O, 1/I/L) are removed
+const codeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// RandomCode returns a random code of given length, easy to be typed by a user
+func RandomCode(length int) (string, error) {
+	alphabetLen := big.NewInt(int64(len(codeAlphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetLen)
+		if err != nil {
+			return "", fmt.Errorf("generate random code: %w", err)
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// RandomToken returns url-safe random token with n bytes of entropy
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate random token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns sha256 (hex) of a random token.
+// Use it only for high entropy secrets (codes, tokens), never for passwords.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,52 @@
+package geo
+
+import "math"
+
+// metersPerDegree is a length of one degree of latitude (and longitude on the equator)
+const metersPerDegree = 111_320.0
+
+// Snap returns the center of a grid cell of about cellMeters x cellMeters containing the point.
+// The grid is fixed, so all points in the cell give the same result - averaging repeated
+// (e.g. noisy) positions doesn't reveal the exact point.
+// Longitude cells are wider in degrees towards the poles, to keep their size in meters.
+func Snap(latitude, longitude, cellMeters float64) (float64, float64) {
+	latStep := cellMeters / metersPerDegree
+	rows := int(math.Ceil(180 / latStep))
+	row := clamp(int(math.Floor((latitude+90)/latStep)), rows)
+	snappedLat := math.Min(-90+(float64(row)+0.5)*latStep, 90)
+
+	// the number of cells depends on the row only, so it's the same for all points in the row
+	rowWidth := 360 * math.Cos(snappedLat*math.Pi/180) * metersPerDegree
+	columns := max(1, int(math.Floor(rowWidth/cellMeters)))
+	lonStep := 360 / float64(columns)
+	column := clamp(int(math.Floor((normalizeLongitude(longitude)+180)/lonStep)), columns)
+	snappedLon := -180 + (float64(column)+0.5)*lonStep
+
+	return snappedLat, snappedLon
+}
+
+// Distance returns the great-circle distance between points in meters
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6_371_000.0
+
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// normalizeLongitude maps longitude to [-180, 180)
+func normalizeLongitude(longitude float64) float64 {
+	l := math.Mod(longitude+180, 360)
+	if l < 0 {
+		l += 360
+	}
+	return l - 180
+}
+
+func clamp(i, n int) int {
+	return min(max(i, 0), n-1)
+}
@@ -0,0 +1,90 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Snap_SameCell_SameResult(t *testing.T) {
+	lat, lon := Snap(52.229676, 21.012229, 1000)
+	for _, d := range []float64{-0.0004, -0.0001, 0, 0.0001, 0.0004} {
+		lat2, lon2 := Snap(lat+d, lon+d, 1000)
+		if lat2 != lat || lon2 != lon {
+			t.Fatalf("point moved by %f snapped to other cell: %f,%f, should be: %f,%f", d, lat2, lon2, lat, lon)
+		}
+	}
+}
+
+func Test_Snap_Idempotent(t *testing.T) {
+	for _, cell := range []float64{1000, 10000} {
+		lat, lon := Snap(-33.868820, 151.209290, cell)
+		lat2, lon2 := Snap(lat, lon, cell)
+		if lat2 != lat || lon2 != lon {
+			t.Fatalf("snapped point is not a fixed point for %f m: %f,%f -> %f,%f", cell, lat, lon, lat2, lon2)
+		}
+	}
+}
+
+func Test_Snap_WithinCell(t *testing.T) {
+	type tc struct {
+		lat, lon float64
+	}
+
+	tcs := []tc{
+		{lat: 0, lon: 0},
+		{lat: 52.229676, lon: 21.012229},
+		{lat: -33.868820, lon: 151.209290},
+		{lat: 64.146582, lon: -21.942635},
+		{lat: 78.223172, lon: 15.626723},
+		{lat: 37.774929, lon: -122.419416},
+	}
+
+	for _, cell := range []float64{1000, 10000} {
+		for _, test := range tcs {
+			lat, lon := Snap(test.lat, test.lon, cell)
+			// the farthest point of a cell is its corner
+			if d := Distance(test.lat, test.lon, lat, lon); d > cell*math.Sqrt2/2*1.05 {
+				t.Fatalf("%f,%f snapped too far for %f m cell: %f m", test.lat, test.lon, cell, d)
+			}
+			if d := Distance(test.lat, test.lon, lat, lon); cell == 10000 && d < 1 {
+				t.Fatalf("%f,%f not snapped", test.lat, test.lon)
+			}
+		}
+	}
+}
+
+func Test_Snap_Edges(t *testing.T) {
+	type tc struct {
+		lat, lon float64
+	}
+
+	tcs := []tc{
+		{lat: 90, lon: 0},
+		{lat: -90, lon: 0},
+		{lat: 89.9999, lon: 179.9999},
+		{lat: 0, lon: 180},
+		{lat: 0, lon: -180},
+		{lat: 10, lon: 540},
+	}
+
+	for _, test := range tcs {
+		lat, lon := Snap(test.lat, test.lon, 10000)
+		if lat < -90 || lat > 90 || lon < -180 || lon >= 180 {
+			t.Fatalf("%f,%f snapped out of range: %f,%f", test.lat, test.lon, lat, lon)
+		}
+	}
+
+	lat, lon := Snap(0, 180, 10000)
+	lat2, lon2 := Snap(0, -180, 10000)
+	if lat != lat2 || lon != lon2 {
+		t.Fatalf("180 and -180 longitudes snapped to different cells: %f,%f and %f,%f", lat, lon, lat2, lon2)
+	}
+}
+
+func Test_Distance(t *testing.T) {
+	// Warsaw - Krakow, about 252 km
+	d := Distance(52.229676, 21.012229, 50.064650, 19.944980)
+	if d < 250_000 || d > 254_000 {
+		t.Fatalf("unexpected distance: %f", d)
+	}
+}
@@ -0,0 +1,297 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Key is a key used for signing and verifying tokens.
+type Key struct {
+	// ID is a key ID, put in `kid` header of signed tokens
+	ID string
+	// Method is a signing method (HS256, RS256 or EdDSA)
+	Method jwt.SigningMethod
+	// SignKey is a private key (or hmac secret), nil for verify-only keys
+	SignKey any
+	// VerifyKey is a public key (or hmac secret)
+	VerifyKey any
+	// RetireAt tells when the key stops being accepted (nil - never).
+	// Keys being rotated out should have it set to the expiration time of the longest living token.
+	RetireAt *time.Time
+}
+
+func (k Key) retired(now time.Time) bool {
+	return k.RetireAt != nil && !now.Before(*k.RetireAt)
+}
+
+// KeySet is a set of keys used for verification, one of them is used for signing new tokens.
+type KeySet struct {
+	signingKeyID string
+	keys         map[string]Key
+}
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrKeyRetired = errors.New("signing key is retired")
+)
+
+// NewKeySet returns a new key set, signingKeyID must point to one of the keys with SignKey.
+func NewKeySet(signingKeyID string, keys ...Key) (*KeySet, error) {
+	set := &KeySet{
+		signingKeyID: signingKeyID,
+		keys:         make(map[string]Key, len(keys)),
+	}
+
+	for _, k := range keys {
+		if err := set.Add(k); err != nil {
+			return nil, err
+		}
+	}
+
+	signing, ok := set.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q: %w", signingKeyID, ErrUnknownKey)
+	}
+	if signing.SignKey == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingKeyID)
+	}
+	if signing.RetireAt != nil {
+		return nil, fmt.Errorf("signing key %q cannot be retired", signingKeyID)
+	}
+
+	return set, nil
+}
+
+// NewHMACKeySet returns a key set with a single HS256 key without ID.
+func NewHMACKeySet(secret []byte) *KeySet {
+	return &KeySet{
+		signingKeyID: "",
+		keys: map[string]Key{
+			"": NewHMACKey("", secret),
+		},
+	}
+}
+
+// NewHMACKey returns HS256 key.
+func NewHMACKey(kid string, secret []byte) Key {
+	return Key{
+		ID:        kid,
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   secret,
+		VerifyKey: secret,
+	}
+}
+
+// Add adds a key to the set, key IDs must be unique.
+func (s *KeySet) Add(key Key) error {
+	if key.Method == nil || key.VerifyKey == nil {
+		return fmt.Errorf("key %q: missing method or verify key", key.ID)
+	}
+	if _, ok := s.keys[key.ID]; ok {
+		return fmt.Errorf("key %q: duplicated key id", key.ID)
+	}
+
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *KeySet) signingKey() Key {
+	return s.keys[s.signingKeyID]
+}
+
+// verifyKey returns a key for the token verification.
+func (s *KeySet) verifyKey(token *jwt.Token, now time.Time) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	// never trust alg header, the key decides about the algorithm
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+	}
+
+	if key.retired(now) {
+		return nil, ErrKeyRetired
+	}
+
+	return key.VerifyKey, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519) keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public keys of the set which are not retired.
+// Symmetric keys are never published.
+func (s *KeySet) JWKS(now time.Time) JWKS {
+	result := JWKS{Keys: []JWK{}}
+	for _, k := range s.keys {
+		if k.retired(now) {
+			continue
+		}
+
+		switch pub := k.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			result.Keys = append(result.Keys, JWK{
+				Kty: "RSA",
+				Kid: k.ID,
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			result.Keys = append(result.Keys, JWK{
+				Kty: "OKP",
+				Kid: k.ID,
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	return result
+}
+
+type keySetFile struct {
+	SigningKey string        `json:"signing_key"`
+	Keys       []keyFileItem `json:"keys"`
+}
+
+type keyFileItem struct {
+	Kid string `json:"kid"`
+	// Alg is RS256 or EdDSA
+	Alg string `json:"alg"`
+	// PrivateKey is a path to PEM encoded private key
+	PrivateKey string `json:"private_key"`
+	// PublicKey is a path to PEM encoded public key, used when PrivateKey is empty
+	PublicKey string `json:"public_key"`
+	// RetireAt RFC3339 time
+	RetireAt *time.Time `json:"retire_at"`
+}
+
+// LoadKeySet loads a key set from JSON file:
+//
+//	{
+//	  "signing_key": "2024-10",
+//	  "keys": [
+//	    {"kid": "2024-10", "alg": "EdDSA", "private_key": "./.env/jwt-2024-10.pem"},
+//	    {
+//	      "kid": "2024-04", "alg": "RS256", "public_key": "./.env/jwt-2024-04.pub.pem",
+//	      "retire_at": "2024-11-15T00:00:00Z"
+//	    }
+//	  ]
+//	}
+func LoadKeySet(filePath string) (*KeySet, error) {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("load key set: %w", err)
+	}
+
+	var f keySetFile
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return nil, fmt.Errorf("unmarshall key set: %w", err)
+	}
+
+	keys := make([]Key, 0, len(f.Keys))
+	for _, item := range f.Keys {
+		k, err := item.load()
+		if err != nil {
+			return nil, fmt.Errorf("load key %q: %w", item.Kid, err)
+		}
+		keys = append(keys, k)
+	}
+
+	return NewKeySet(f.SigningKey, keys...)
+}
+
+func (i keyFileItem) load() (Key, error) {
+	if i.Kid == "" {
+		return Key{}, errors.New("missing kid")
+	}
+
+	key := Key{
+		ID:       i.Kid,
+		RetireAt: i.RetireAt,
+	}
+
+	pemPath := i.PrivateKey
+	private := true
+	if pemPath == "" {
+		pemPath = i.PublicKey
+		private = false
+	}
+	pem, err := os.ReadFile(pemPath)
+	if err != nil {
+		return Key{}, fmt.Errorf("read pem: %w", err)
+	}
+
+	switch i.Alg {
+	case jwt.SigningMethodRS256.Alg():
+		key.Method = jwt.SigningMethodRS256
+		if private {
+			pk, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return Key{}, fmt.Errorf("parse private key: %w", err)
+			}
+			key.SignKey, key.VerifyKey = pk, &pk.PublicKey
+		} else {
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return Key{}, fmt.Errorf("parse public key: %w", err)
+			}
+			key.VerifyKey = pub
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		key.Method = jwt.SigningMethodEdDSA
+		if private {
+			pk, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return Key{}, fmt.Errorf("parse private key: %w", err)
+			}
+			edKey, ok := pk.(ed25519.PrivateKey)
+			if !ok {
+				return Key{}, errors.New("not an ed25519 private key")
+			}
+			key.SignKey, key.VerifyKey = edKey, edKey.Public()
+		} else {
+			pub, err := jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return Key{}, fmt.Errorf("parse public key: %w", err)
+			}
+			key.VerifyKey = pub
+		}
+	default:
+		return Key{}, fmt.Errorf("unsupported alg: %s", i.Alg)
+	}
+
+	return key, nil
+}
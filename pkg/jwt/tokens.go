@@ -10,43 +10,93 @@ import (
 	"whereiseveryone/pkg/timer"
 )
 
+// TokenType tells what the token can be used for.
+type TokenType string
+
+const (
+	// TokenTypeAccess is a token used as a bearer credential.
+	TokenTypeAccess TokenType = "access"
+	// TokenTypeRefresh is a token that can be only exchanged for a new pair of tokens.
+	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeChallenge is a short-lived token proving the password was verified,
+	// it can be only exchanged for a pair of tokens with the second factor.
+	TokenTypeChallenge TokenType = "mfa"
+	// TokenTypeShareLink is a token of a public location share link, SessionID is an ID of the link.
+	// It gives access only to the location of the user who created the link.
+	TokenTypeShareLink TokenType = "link"
+)
+
+// Config is a configuration of issued tokens.
+type Config struct {
+	// Issuer is put in `iss` claim, tokens with different issuer are rejected
+	Issuer string
+	// Audience is put in `aud` claim, tokens with different audience are rejected
+	Audience string
+	// AccessValidity is a lifetime of access tokens
+	AccessValidity time.Duration
+	// RefreshValidity is a lifetime of refresh tokens
+	RefreshValidity time.Duration
+	// ChallengeValidity is a lifetime of challenge (second factor) tokens
+	ChallengeValidity time.Duration
+	// Leeway is an allowed clock skew used for exp, nbf and iat validation
+	Leeway time.Duration
+}
+
 type JWT struct {
-	timer    timer.Timer
-	secret   []byte
-	validity time.Duration
+	timer  timer.Timer
+	keys   *KeySet
+	config Config
 }
 
-func NewJWT(timer timer.Timer, secret []byte, validity time.Duration) *JWT {
-	return &JWT{timer: timer, secret: secret, validity: validity}
+func NewJWT(timer timer.Timer, keys *KeySet, config Config) *JWT {
+	return &JWT{
+		timer:  timer,
+		keys:   keys,
+		config: config,
+	}
 }
 
+// SignedToken is a claims set of tokens issued by the app.
+// Subject is an ID of the user the token was issued for, Id is an unique token ID (jti).
+// Scopes tell what the token can be used for (nil for tokens issued before scopes were introduced).
+// IssuedAtMs is `iat` with millisecond precision, it orders tokens issued within the same second (see IssuedTime).
 type SignedToken struct {
-	UserName string
-	ID       string
+	UserName   string
+	ID         string
+	Type       TokenType `json:"token_type"`
+	SessionID  string    `json:"sid"`
+	Scopes     []string  `json:"scopes,omitempty"`
+	IssuedAtMs int64     `json:"iat_ms,omitempty"`
 
 	jwt.StandardClaims
 }
 
-var ErrTokenExpired = errors.New("token is expired")
+var (
+	ErrMissingClaim        = errors.New("missing required claim")
+	ErrTokenExpired        = errors.New("token is expired")
+	ErrTokenNotValidYet    = errors.New("token is not valid yet")
+	ErrTokenIssuedInFuture = errors.New("token is issued in the future")
+	ErrInvalidIssuer       = errors.New("invalid token issuer")
+	ErrInvalidAudience     = errors.New("invalid token audience")
+	ErrInvalidTokenType    = errors.New("invalid token type")
+)
 
-func (j JWT) GenerateTokens(username string, id id.ID) (string, string, error) {
-	claims := SignedToken{
-		UserName: username,
-		ID:       id.Hex(),
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: j.timer.Now().Add(j.validity).Unix(),
-		},
+// IssuedTime returns the time the token was issued at, with millisecond precision if the token has `iat_ms` claim.
+func (t SignedToken) IssuedTime() time.Time {
+	if t.IssuedAtMs != 0 {
+		return time.UnixMilli(t.IssuedAtMs).UTC()
 	}
 
-	refresh := jwt.StandardClaims{
-		ExpiresAt: j.timer.Now().Add(j.validity).Unix(),
-	}
+	return time.Unix(t.IssuedAt, 0).UTC()
+}
 
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+// GenerateTokens returns a pair of access and refresh tokens with the scopes issued for the user session.
+func (j JWT) GenerateTokens(username string, id, sessionID id.ID, scopes []string) (string, string, error) {
+	token, err := j.sign(username, id, sessionID, scopes, TokenTypeAccess, j.config.AccessValidity)
 	if err != nil {
 		return "", "", fmt.Errorf("create token: %w", err)
 	}
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refresh).SignedString(j.secret)
+	refreshToken, err := j.sign(username, id, sessionID, scopes, TokenTypeRefresh, j.config.RefreshValidity)
 	if err != nil {
 		return "", "", fmt.Errorf("create refresh token: %w", err)
 	}
@@ -54,26 +104,180 @@ func (j JWT) GenerateTokens(username string, id id.ID) (string, string, error) {
 	return token, refreshToken, nil
 }
 
+// GenerateChallengeToken returns a challenge token issued for the user after the password verification.
+// The token is not bound to any session, scopes are the scopes requested for tokens.
+func (j JWT) GenerateChallengeToken(username string, userID id.ID, scopes []string) (string, error) {
+	token, err := j.sign(username, userID, id.ZeroID, scopes, TokenTypeChallenge, j.config.ChallengeValidity)
+	if err != nil {
+		return "", fmt.Errorf("create challenge token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GenerateShareLinkToken returns a token of the user share link, valid until the link expires.
+func (j JWT) GenerateShareLinkToken(userID, linkID id.ID, expiresAt time.Time) (string, error) {
+	token, err := j.sign("", userID, linkID, nil, TokenTypeShareLink, expiresAt.Sub(j.timer.Now()))
+	if err != nil {
+		return "", fmt.Errorf("create share link token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ValidateToken validates signed access token and returns its claims.
+// Refresh tokens are rejected with ErrInvalidTokenType.
+// Access tokens issued before token types were introduced are accepted until they expire.
 func (j JWT) ValidateToken(signed string) (SignedToken, error) {
-	token, err := jwt.ParseWithClaims(
+	return j.validate(signed, TokenTypeAccess)
+}
+
+// ValidateRefreshToken validates signed refresh token and returns its claims.
+// Access tokens are rejected with ErrInvalidTokenType.
+func (j JWT) ValidateRefreshToken(signed string) (SignedToken, error) {
+	return j.validate(signed, TokenTypeRefresh)
+}
+
+// ValidateChallengeToken validates signed challenge token and returns its claims.
+func (j JWT) ValidateChallengeToken(signed string) (SignedToken, error) {
+	return j.validate(signed, TokenTypeChallenge)
+}
+
+// ValidateShareLinkToken validates signed share link token and returns its claims.
+// The link can be revoked before the token expires, it has to be checked by the caller.
+func (j JWT) ValidateShareLinkToken(signed string) (SignedToken, error) {
+	return j.validate(signed, TokenTypeShareLink)
+}
+
+func (j JWT) sign(
+	username string,
+	userID, sessionID id.ID,
+	scopes []string,
+	tokenType TokenType,
+	validity time.Duration,
+) (string, error) {
+	now := j.timer.Now()
+	claims := SignedToken{
+		UserName:   username,
+		ID:         userID.Hex(),
+		Type:       tokenType,
+		SessionID:  sessionID.Hex(),
+		Scopes:     scopes,
+		IssuedAtMs: now.UnixMilli(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        newTokenID(),
+			Issuer:    j.config.Issuer,
+			Audience:  j.config.Audience,
+			Subject:   userID.Hex(),
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(validity).Unix(),
+		},
+	}
+
+	key := j.keys.signingKey()
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	signed, err := token.SignedString(key.SignKey)
+	if err != nil {
+		return "", fmt.Errorf("sign %s token: %w", tokenType, err)
+	}
+
+	return signed, nil
+}
+
+func (j JWT) validate(signed string, tokenType TokenType) (SignedToken, error) {
+	// claims are validated below, using app timer and leeway
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(
 		signed,
 		&SignedToken{},
 		func(token *jwt.Token) (any, error) {
-			return j.secret, nil
+			return j.keys.verifyKey(token, j.timer.Now())
 		})
 
 	if err != nil {
+		// unwrap errors returned by key func (jwt.ValidationError doesn't support errors.Is)
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Inner != nil {
+			err = validationErr.Inner
+		}
 		return SignedToken{}, fmt.Errorf("parse token: %w", err)
 	}
 
 	claims, ok := token.Claims.(*SignedToken)
 	if !ok {
-		return SignedToken{}, fmt.Errorf("invalid token: %w", err)
+		return SignedToken{}, errors.New("invalid token claims")
 	}
 
-	if claims.ExpiresAt < j.timer.Now().Unix() {
-		return SignedToken{}, ErrTokenExpired
+	if claims.Type == "" && tokenType == TokenTypeAccess && claims.ID != "" && claims.Subject == "" {
+		return j.validateLegacyAccess(*claims)
+	}
+	if claims.Type != tokenType {
+		return SignedToken{}, ErrInvalidTokenType
+	}
+
+	if err := j.validateClaims(claims.StandardClaims); err != nil {
+		return SignedToken{}, err
 	}
 
 	return *claims, nil
 }
+
+// validateLegacyAccess validates access token issued before token types were introduced, it's accepted until
+// it expires. Such tokens have only user claims and expiration (refresh tokens of that time had no user claims).
+// The subject is set to the user ID, so the token is revoked with other user tokens (it has no issued time).
+func (j JWT) validateLegacyAccess(claims SignedToken) (SignedToken, error) {
+	if claims.ExpiresAt == 0 {
+		return SignedToken{}, ErrMissingClaim
+	}
+	if j.timer.Now().Unix() > claims.ExpiresAt+int64(j.config.Leeway.Seconds()) {
+		return SignedToken{}, ErrTokenExpired
+	}
+
+	claims.Type = TokenTypeAccess
+	claims.Subject = claims.ID
+
+	return claims, nil
+}
+
+// validateClaims validates registered claims, all of them are required.
+func (j JWT) validateClaims(claims jwt.StandardClaims) error {
+	now := j.timer.Now()
+	leeway := int64(j.config.Leeway.Seconds())
+
+	if claims.ExpiresAt == 0 || claims.IssuedAt == 0 {
+		return ErrMissingClaim
+	}
+	if now.Unix() > claims.ExpiresAt+leeway {
+		return ErrTokenExpired
+	}
+	if now.Unix() < claims.NotBefore-leeway {
+		return ErrTokenNotValidYet
+	}
+	if now.Unix() < claims.IssuedAt-leeway {
+		return ErrTokenIssuedInFuture
+	}
+	if claims.Issuer != j.config.Issuer {
+		return ErrInvalidIssuer
+	}
+	if claims.Audience != j.config.Audience {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+// JWKS returns public keys which can be used to verify issued tokens.
+func (j JWT) JWKS() JWKS {
+	return j.keys.JWKS(j.timer.Now())
+}
+
+// newTokenID returns a unique token identifier (jti).
+// It makes tokens generated within the same second distinguishable.
+func newTokenID() string {
+	return id.NewID().Hex()
+}
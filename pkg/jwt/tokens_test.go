@@ -0,0 +1,279 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"whereiseveryone/pkg/id"
+	"whereiseveryone/pkg/pointers"
+)
+
+var testConfig = Config{
+	Issuer:            "issuer",
+	Audience:          "audience",
+	AccessValidity:    time.Hour,
+	RefreshValidity:   24 * time.Hour,
+	ChallengeValidity: 5 * time.Minute,
+	Leeway:            time.Minute,
+}
+
+type fakeTimer struct {
+	now time.Time
+}
+
+func (f *fakeTimer) Now() time.Time {
+	return f.now
+}
+
+func newEdKey(t *testing.T, kid string) Key {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return Key{ID: kid, Method: jwt.SigningMethodEdDSA, SignKey: priv, VerifyKey: pub}
+}
+
+func Test_GenerateAndValidate_HMAC(t *testing.T) {
+	tm := &fakeTimer{now: time.Now()}
+	j := NewJWT(tm, NewHMACKeySet([]byte("secret")), testConfig)
+
+	userID, sessionID := id.NewID(), id.NewID()
+	token, refresh, err := j.GenerateTokens("user", userID, sessionID, []string{"location:write"})
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+
+	claims, err := j.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("validate token: %v", err)
+	}
+	if claims.Subject != userID.Hex() || claims.SessionID != sessionID.Hex() || claims.Type != TokenTypeAccess ||
+		len(claims.Scopes) != 1 || claims.Scopes[0] != "location:write" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	if _, err := j.ValidateToken(refresh); !errors.Is(err, ErrInvalidTokenType) {
+		t.Fatalf("refresh token accepted as access token, err: %v", err)
+	}
+	if _, err := j.ValidateRefreshToken(refresh); err != nil {
+		t.Fatalf("validate refresh token: %v", err)
+	}
+
+	challenge, err := j.GenerateChallengeToken("user", userID, nil)
+	if err != nil {
+		t.Fatalf("generate challenge token: %v", err)
+	}
+	if _, err := j.ValidateToken(challenge); !errors.Is(err, ErrInvalidTokenType) {
+		t.Fatalf("challenge token accepted as access token, err: %v", err)
+	}
+	if claims, err := j.ValidateChallengeToken(challenge); err != nil || claims.Subject != userID.Hex() {
+		t.Fatalf("validate challenge token: %v, claims: %+v", err, claims)
+	}
+}
+
+func Test_ShareLinkToken(t *testing.T) {
+	tm := &fakeTimer{now: time.Now()}
+	j := NewJWT(tm, NewHMACKeySet([]byte("secret")), testConfig)
+
+	userID, linkID := id.NewID(), id.NewID()
+	link, err := j.GenerateShareLinkToken(userID, linkID, tm.now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("generate share link token: %v", err)
+	}
+	if _, err := j.ValidateToken(link); !errors.Is(err, ErrInvalidTokenType) {
+		t.Fatalf("share link token accepted as access token, err: %v", err)
+	}
+
+	claims, err := j.ValidateShareLinkToken(link)
+	if err != nil {
+		t.Fatalf("validate share link token: %v", err)
+	}
+	if claims.Subject != userID.Hex() || claims.SessionID != linkID.Hex() {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	tm.now = tm.now.Add(2*time.Hour + 2*testConfig.Leeway)
+	if _, err := j.ValidateShareLinkToken(link); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expired share link token accepted, err: %v", err)
+	}
+}
+
+func Test_KeyRotation(t *testing.T) {
+	tm := &fakeTimer{now: time.Now()}
+	oldKey := newEdKey(t, "old")
+	oldSet, err := NewKeySet("old", oldKey)
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+	oldToken, _, err := NewJWT(tm, oldSet, testConfig).GenerateTokens("user", id.NewID(), id.NewID(), nil)
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+
+	// rotate: new signing key, the old one is accepted for an hour
+	oldKey.SignKey = nil
+	oldKey.RetireAt = pointers.Pointer(tm.now.Add(time.Hour))
+	newSet, err := NewKeySet("new", newEdKey(t, "new"), oldKey)
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+	j := NewJWT(tm, newSet, testConfig)
+
+	if _, err := j.ValidateToken(oldToken); err != nil {
+		t.Fatalf("token signed by old key should be valid in grace period: %v", err)
+	}
+	if len(j.JWKS().Keys) != 2 {
+		t.Fatalf("both keys should be published in grace period")
+	}
+
+	tm.now = tm.now.Add(time.Hour)
+	if _, err := j.ValidateToken(oldToken); !errors.Is(err, ErrKeyRetired) {
+		t.Fatalf("token signed by retired key should be rejected, err: %v", err)
+	}
+	if keys := j.JWKS().Keys; len(keys) != 1 || keys[0].Kid != "new" || keys[0].Crv != "Ed25519" {
+		t.Fatalf("only the new key should be published, is: %+v", keys)
+	}
+}
+
+func Test_RejectsUnexpectedAlgorithm(t *testing.T) {
+	tm := &fakeTimer{now: time.Now()}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	set, err := NewKeySet("rsa", Key{ID: "rsa", Method: jwt.SigningMethodRS256, SignKey: rsaKey, VerifyKey: &rsaKey.PublicKey})
+	if err != nil {
+		t.Fatalf("new key set: %v", err)
+	}
+	j := NewJWT(tm, set, testConfig)
+
+	// token signed with HS256 using key id of RSA key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, SignedToken{
+		Type:           TokenTypeAccess,
+		StandardClaims: jwt.StandardClaims{ExpiresAt: tm.now.Add(time.Hour).Unix()},
+	})
+	forged.Header["kid"] = "rsa"
+	signed, err := forged.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	if _, err := j.ValidateToken(signed); err == nil {
+		t.Fatalf("token with unexpected algorithm should be rejected")
+	}
+	if keys := j.JWKS().Keys; len(keys) != 1 || keys[0].Kty != "RSA" {
+		t.Fatalf("rsa key should be published, is: %+v", keys)
+	}
+}
+
+func Test_ValidateClaims(t *testing.T) {
+	issuedAt := time.Now()
+	tm := &fakeTimer{now: issuedAt}
+	keys := NewHMACKeySet([]byte("secret"))
+	token, _, err := NewJWT(tm, keys, testConfig).GenerateTokens("user", id.NewID(), id.NewID(), nil)
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+
+	otherAudience := testConfig
+	otherAudience.Audience = "other"
+	otherIssuer := testConfig
+	otherIssuer.Issuer = "other"
+
+	type tc struct {
+		name   string
+		now    time.Time
+		config Config
+		err    error
+	}
+
+	tcs := []tc{
+		{name: "valid", now: issuedAt, config: testConfig, err: nil},
+		{name: "clock skew within leeway", now: issuedAt.Add(-30 * time.Second), config: testConfig, err: nil},
+		{name: "not valid yet", now: issuedAt.Add(-2 * time.Minute), config: testConfig, err: ErrTokenNotValidYet},
+		{name: "expired within leeway", now: issuedAt.Add(time.Hour + 30*time.Second), config: testConfig, err: nil},
+		{name: "expired", now: issuedAt.Add(time.Hour + 2*time.Minute), config: testConfig, err: ErrTokenExpired},
+		{name: "other audience", now: issuedAt, config: otherAudience, err: ErrInvalidAudience},
+		{name: "other issuer", now: issuedAt, config: otherIssuer, err: ErrInvalidIssuer},
+	}
+
+	for _, test := range tcs {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewJWT(&fakeTimer{now: test.now}, keys, test.config).ValidateToken(token)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("unexpected err, is: %v, should be: %v", err, test.err)
+			}
+		})
+	}
+}
+
+func Test_IssuedTime(t *testing.T) {
+	tm := &fakeTimer{now: time.Date(2024, 5, 1, 12, 0, 0, 750*int(time.Millisecond), time.UTC)}
+	j := NewJWT(tm, NewHMACKeySet([]byte("secret")), testConfig)
+
+	token, _, err := j.GenerateTokens("user", id.NewID(), id.NewID(), nil)
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+	claims, err := j.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("validate token: %v", err)
+	}
+	if !claims.IssuedTime().Equal(tm.now) {
+		t.Fatalf("issued time should have millisecond precision, is: %s", claims.IssuedTime())
+	}
+
+	// tokens issued before iat_ms was introduced
+	claims.IssuedAtMs = 0
+	if !claims.IssuedTime().Equal(tm.now.Truncate(time.Second)) {
+		t.Fatalf("issued time should fall back to iat, is: %s", claims.IssuedTime())
+	}
+}
+
+func Test_LegacyTokens(t *testing.T) {
+	now := time.Now()
+	j := NewJWT(&fakeTimer{now: now}, NewHMACKeySet([]byte("secret")), testConfig)
+	userID := id.NewID()
+
+	sign := func(claims jwt.Claims) string {
+		t.Helper()
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+	// claims of tokens issued before token types were introduced
+	access := func(expiresAt time.Time) string {
+		return sign(struct {
+			UserName string
+			ID       string
+			jwt.StandardClaims
+		}{UserName: "user", ID: userID.Hex(), StandardClaims: jwt.StandardClaims{ExpiresAt: expiresAt.Unix()}})
+	}
+	refresh := sign(jwt.StandardClaims{Id: id.NewID().Hex(), Subject: userID.Hex(), ExpiresAt: now.Add(time.Hour).Unix()})
+
+	claims, err := j.ValidateToken(access(now.Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("validate legacy access token: %v", err)
+	}
+	if claims.Type != TokenTypeAccess || claims.ID != userID.Hex() || claims.Subject != userID.Hex() {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	if _, err := j.ValidateToken(access(now.Add(-time.Hour))); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expired legacy access token, err: %v", err)
+	}
+	if _, err := j.ValidateToken(refresh); !errors.Is(err, ErrInvalidTokenType) {
+		t.Fatalf("legacy refresh token used as access token, err: %v", err)
+	}
+	if _, err := j.ValidateRefreshToken(access(now.Add(time.Hour))); !errors.Is(err, ErrInvalidTokenType) {
+		t.Fatalf("legacy access token used as refresh token, err: %v", err)
+	}
+}
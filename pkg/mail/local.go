@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"whereiseveryone/pkg/logger"
+)
+
+type logSender struct {
+	logger logger.Logger
+}
+
+// NewLogSender returns a sender which only logs messages, for local development.
+func NewLogSender(logger logger.Logger) *logSender {
+	return &logSender{logger}
+}
+
+func (s *logSender) Send(_ context.Context, msg Message) error {
+	s.logger.
+		WithField("to", msg.To).
+		WithField("subject", msg.Subject).
+		Infof("mail message:\n%s", msg.Body)
+	return nil
+}
+
+type fileSender struct {
+	dir string
+}
+
+// NewFileSender returns a sender which writes each message to a separate file in dir, for tests.
+func NewFileSender(dir string) *fileSender {
+	return &fileSender{dir}
+}
+
+func (s *fileSender) Send(_ context.Context, msg Message) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return fmt.Errorf("create mail dir: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.txt", time.Now().UnixNano(), filepath.Base(msg.To))
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(content), 0o600); err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+
+	return nil
+}
+
+var (
+	_ Sender = (*logSender)(nil)
+	_ Sender = (*fileSender)(nil)
+)
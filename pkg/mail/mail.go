@@ -0,0 +1,15 @@
+package mail
+
+import "context"
+
+// Message is a plain text message sent to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages to users
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,236 @@
+// Package oidc implements OpenID Connect relying party: authorization code flow with PKCE
+// and ID token verification against the provider's JWKS.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"whereiseveryone/pkg/timer"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+// maxResponseSize limits responses read from the provider
+const maxResponseSize = 1 << 20
+
+var ErrDiscovery = errors.New("provider discovery failed")
+
+// Config is a configuration of a single provider.
+type Config struct {
+	// Issuer is the provider issuer URL, discovery document is fetched from `<issuer>/.well-known/openid-configuration`
+	Issuer string `json:"issuer"`
+	// ClientID is the app client ID registered in the provider
+	ClientID string `json:"client_id"`
+	// ClientSecret is the app client secret (empty for public clients)
+	ClientSecret string `json:"client_secret"`
+	// RedirectURL is the app callback URL registered in the provider
+	RedirectURL string `json:"redirect_url"`
+	// Scopes are requested scopes, `openid` is always requested
+	Scopes []string `json:"scopes"`
+}
+
+// discovery is the subset of OpenID Provider Metadata used by the client
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is a client of a single OpenID provider.
+// Provider metadata and keys are fetched lazily and cached, so the app starts even if the provider is down.
+type Provider struct {
+	config Config
+	client *http.Client
+	timer  timer.Timer
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]any
+	keysAt    time.Time
+}
+
+func NewProvider(client *http.Client, timer timer.Timer, config Config) *Provider {
+	return &Provider{
+		config: config,
+		client: client,
+		timer:  timer,
+	}
+}
+
+// AuthCodeURL returns the URL of the provider authorization endpoint the user should be redirected to.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid authorization endpoint: %w", ErrDiscovery, err)
+	}
+
+	params := u.Query()
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", p.scope())
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+	u.RawQuery = params.Encode()
+
+	return u.String(), nil
+}
+
+// Token is a token endpoint response
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// Exchange exchanges the authorization code for tokens.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (Token, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var token Token
+	if err := p.do(req, &token); err != nil {
+		return Token{}, fmt.Errorf("exchange code: %w", err)
+	}
+	if token.IDToken == "" {
+		return Token{}, errors.New("exchange code: missing id_token")
+	}
+
+	return token, nil
+}
+
+func (p *Provider) scope() string {
+	scopes := []string{"openid"}
+	for _, s := range p.config.Scopes {
+		if s != "openid" {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return strings.Join(scopes, " ")
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + discoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+
+	var d discovery
+	if err := p.do(req, &d); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+	if d.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch, is: %s, should be: %s", ErrDiscovery, d.Issuer, p.config.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: missing endpoints", ErrDiscovery)
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *Provider) do(req *http.Request, result any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("perform request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("unmarshall response: %w", err)
+	}
+
+	return nil
+}
+
+type configFile struct {
+	Providers map[string]Config `json:"providers"`
+}
+
+// LoadConfigs loads provider configurations from JSON file, keys are provider names used in URLs:
+//
+//	{
+//	  "providers": {
+//	    "corp": {
+//	      "issuer": "https://login.example.com",
+//	      "client_id": "whereiseveryone",
+//	      "client_secret": "secret",
+//	      "redirect_url": "https://api.example.com/api/auth/oidc/corp/callback",
+//	      "scopes": ["email", "profile"]
+//	    }
+//	  }
+//	}
+func LoadConfigs(filePath string) (map[string]Config, error) {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("load oidc providers: %w", err)
+	}
+
+	var f configFile
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return nil, fmt.Errorf("unmarshall oidc providers: %w", err)
+	}
+
+	for name, c := range f.Providers {
+		if c.Issuer == "" || c.ClientID == "" || c.RedirectURL == "" {
+			return nil, fmt.Errorf("provider %q: issuer, client_id and redirect_url are required", name)
+		}
+	}
+
+	return f.Providers, nil
+}
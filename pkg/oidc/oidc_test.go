@@ -0,0 +1,226 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type fakeTimer struct {
+	now time.Time
+}
+
+func (f *fakeTimer) Now() time.Time {
+	return f.now
+}
+
+// mockIssuer is a minimal OpenID provider, it issues ID tokens for a single authorization code
+type mockIssuer struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	code      string
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m := &mockIssuer{key: key, clientID: "client"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("code") != m.code || CodeChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": m.sign(t, m.claims)})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+func (m *mockIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "mock"
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+func (m *mockIssuer) provider(tm *fakeTimer) *Provider {
+	return NewProvider(m.server.Client(), tm, Config{
+		Issuer:      m.server.URL,
+		ClientID:    m.clientID,
+		RedirectURL: "http://localhost/callback",
+		Scopes:      []string{"email"},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func Test_AuthorizationCodeFlow(t *testing.T) {
+	tm := &fakeTimer{now: time.Now()}
+	issuer := newMockIssuer(t)
+	p := issuer.provider(tm)
+	ctx := context.Background()
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("new pkce: %v", err)
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", challenge)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url: %v", err)
+	}
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("state") != "state" || q.Get("nonce") != "nonce" ||
+		q.Get("code_challenge") != challenge || q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid email" {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+
+	// the user logs in, the provider redirects back with the code
+	issuer.code, issuer.challenge = "code", q.Get("code_challenge")
+	issuer.claims = jwt.MapClaims{
+		"iss":   issuer.server.URL,
+		"sub":   "subject",
+		"aud":   []string{"other", issuer.clientID},
+		"exp":   tm.now.Add(time.Minute).Unix(),
+		"iat":   tm.now.Unix(),
+		"nonce": "nonce",
+		"email": "john@example.com",
+	}
+
+	if _, err := p.Exchange(ctx, "code", "other verifier"); err == nil {
+		t.Fatalf("code exchange with invalid verifier should fail")
+	}
+
+	token, err := p.Exchange(ctx, "code", verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, token.IDToken, "nonce")
+	if err != nil {
+		t.Fatalf("verify id token: %v", err)
+	}
+	if claims.Subject != "subject" || claims.Email != "john@example.com" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func Test_VerifyIDToken(t *testing.T) {
+	tm := &fakeTimer{now: time.Now()}
+	issuer := newMockIssuer(t)
+	p := issuer.provider(tm)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   issuer.server.URL,
+			"sub":   "subject",
+			"aud":   issuer.clientID,
+			"exp":   tm.now.Add(time.Minute).Unix(),
+			"iat":   tm.now.Unix(),
+			"nonce": "nonce",
+		}
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		c := valid()
+		c[key] = value
+		return c
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, valid())
+	forged.Header["kid"] = "mock"
+	forgedToken, err := forged.SignedString(otherKey)
+	if err != nil {
+		t.Fatalf("sign forged token: %v", err)
+	}
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign hmac token: %v", err)
+	}
+
+	type tc struct {
+		name  string
+		token string
+		valid bool
+	}
+
+	tcs := []tc{
+		{name: "valid", token: issuer.sign(t, valid()), valid: true},
+		{name: "other issuer", token: issuer.sign(t, with("iss", "https://other")), valid: false},
+		{name: "other audience", token: issuer.sign(t, with("aud", "other")), valid: false},
+		{name: "other nonce", token: issuer.sign(t, with("nonce", "other")), valid: false},
+		{name: "expired", token: issuer.sign(t, with("exp", tm.now.Add(-2*time.Minute).Unix())), valid: false},
+		{name: "missing subject", token: issuer.sign(t, with("sub", "")), valid: false},
+		{name: "invalid signature", token: forgedToken, valid: false},
+		{name: "hmac", token: hmacToken, valid: false},
+	}
+
+	for _, test := range tcs {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := p.VerifyIDToken(context.Background(), test.token, "nonce")
+			if test.valid && err != nil {
+				t.Fatalf("token should be valid: %v", err)
+			}
+			if !test.valid && !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("token should be invalid, err: %v", err)
+			}
+		})
+	}
+}
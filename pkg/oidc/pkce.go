@@ -0,0 +1,25 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// NewPKCE returns PKCE (RFC 7636) code verifier and its S256 code challenge.
+func NewPKCE() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("generate code verifier: %w", err)
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(buf)
+
+	return verifier, CodeChallenge(verifier), nil
+}
+
+// CodeChallenge returns S256 code challenge of the verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,237 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// keysRefreshInterval limits JWKS fetches triggered by unknown key IDs
+const keysRefreshInterval = time.Minute
+
+// leeway is an allowed clock skew
+const leeway = time.Minute
+
+var (
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrUnknownKey     = errors.New("unknown signing key")
+)
+
+// Claims are ID token claims used by the app
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+	Nonce     string   `json:"nonce"`
+
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// Valid implements jwt.Claims, claims are validated by VerifyIDToken.
+func (c Claims) Valid() error {
+	return nil
+}
+
+// audience is `aud` claim, a single string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return fmt.Errorf("unmarshall aud: %w", err)
+	}
+	*a = multiple
+
+	return nil
+}
+
+// VerifyIDToken verifies the ID token signature (provider's JWKS) and its claims:
+// issuer, audience (client ID), expiration and nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	// signature algorithms supported by the provider keys, `none` and HMAC are never accepted
+	parser := jwt.Parser{
+		ValidMethods:         []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"},
+		SkipClaimsValidation: true,
+	}
+
+	var claims Claims
+	_, err := parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Inner != nil {
+			err = validationErr.Inner
+		}
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	now := p.timer.Now()
+	switch {
+	case claims.Issuer != p.config.Issuer:
+		return Claims{}, fmt.Errorf("%w: invalid issuer", ErrInvalidIDToken)
+	case !slices.Contains(claims.Audience, p.config.ClientID):
+		return Claims{}, fmt.Errorf("%w: invalid audience", ErrInvalidIDToken)
+	case claims.Subject == "":
+		return Claims{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	case claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)):
+		return Claims{}, fmt.Errorf("%w: token is expired", ErrInvalidIDToken)
+	case claims.IssuedAt != 0 && now.Before(time.Unix(claims.IssuedAt, 0).Add(-leeway)):
+		return Claims{}, fmt.Errorf("%w: token is issued in the future", ErrInvalidIDToken)
+	case claims.Nonce != nonce:
+		return Claims{}, fmt.Errorf("%w: invalid nonce", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// key returns the provider public key, keys are refetched when the key is unknown (provider key rotation).
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	fetchedAt := p.keysAt
+	p.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+	if !fetchedAt.IsZero() && p.timer.Now().Sub(fetchedAt) < keysRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+
+	if err := p.fetchKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) error {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return fmt.Errorf("create jwks request: %w", err)
+	}
+
+	var set jwks
+	if err := p.do(req, &set); err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue // unsupported keys are skipped
+		}
+		keys[k.Kid] = pub
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.keysAt = p.timer.Now()
+
+	return nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+
+	return new(big.Int).SetBytes(buf), nil
+}
@@ -0,0 +1,133 @@
+// Package totp implements time-based one-time passwords (RFC 6238) compatible with authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // HMAC-SHA1 is the default of RFC 6238, supported by all authenticator apps
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultDigits is a number of digits of generated codes
+	DefaultDigits = 6
+	// DefaultPeriod is a time step of codes
+	DefaultPeriod = 30 * time.Second
+	// SecretSize is a size of generated secrets in bytes (RFC 4226 recommends 160 bits)
+	SecretSize = 20
+)
+
+var ErrInvalidCode = errors.New("invalid code")
+
+// b32 is base32 without padding, used by authenticator apps
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding) //nolint:gochecknoglobals // cannot be const
+
+// TOTP generates and validates codes.
+type TOTP struct {
+	// Digits is a number of digits of codes
+	Digits int
+	// Period is a time step
+	Period time.Duration
+	// Skew is a number of accepted time steps before and after the current one
+	Skew int64
+}
+
+// New returns TOTP with defaults: 6 digits, 30 seconds and one step of skew.
+func New() TOTP {
+	return TOTP{Digits: DefaultDigits, Period: DefaultPeriod, Skew: 1}
+}
+
+// NewSecret returns a new random secret encoded in base32.
+func NewSecret() (string, error) {
+	buf := make([]byte, SecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+
+	return b32.EncodeToString(buf), nil
+}
+
+// Step returns a time step (counter) for the given time.
+func (t TOTP) Step(at time.Time) int64 {
+	return at.Unix() / int64(t.Period.Seconds())
+}
+
+// Generate returns a code for the base32 encoded secret at the given time.
+func (t TOTP) Generate(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return t.code(key, t.Step(at)), nil
+}
+
+// Validate checks the code and returns the time step it was generated for.
+// Callers should reject steps which were already used to prevent code replays.
+func (t TOTP) Validate(secret, code string, at time.Time) (int64, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	if len(code) != t.Digits {
+		return 0, ErrInvalidCode
+	}
+
+	current := t.Step(at)
+	for step := current - t.Skew; step <= current+t.Skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(t.code(key, step)), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+
+	return 0, ErrInvalidCode
+}
+
+// ProvisioningURI returns `otpauth://` URI, usually presented as QR code to be scanned by an authenticator app.
+func (t TOTP) ProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(t.Digits))
+	params.Set("period", fmt.Sprint(int64(t.Period.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// code is HOTP (RFC 4226) for the counter.
+func (t TOTP) code(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter)) //nolint:gosec // counter is never negative
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range t.Digits {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", t.Digits, value%mod)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret: %w", err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func Test_Generate_RFC6238(t *testing.T) {
+	type tc struct {
+		unix int64
+		code string
+	}
+
+	tcs := []tc{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+		{unix: 20000000000, code: "65353130"},
+	}
+
+	otp := TOTP{Digits: 8, Period: 30 * time.Second}
+	for _, test := range tcs {
+		code, err := otp.Generate(rfcSecret, time.Unix(test.unix, 0))
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		if code != test.code {
+			t.Fatalf("unexpected code at %d, is: %s, should be: %s", test.unix, code, test.code)
+		}
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	otp := New()
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatalf("new secret: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	code, err := otp.Generate(secret, now)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	step, err := otp.Validate(secret, code, now.Add(otp.Period))
+	if err != nil {
+		t.Fatalf("code from the previous step should be accepted: %v", err)
+	}
+	if step != otp.Step(now) {
+		t.Fatalf("unexpected step, is: %d, should be: %d", step, otp.Step(now))
+	}
+
+	if _, err := otp.Validate(secret, code, now.Add(2*otp.Period)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("code outside of skew should be rejected, err: %v", err)
+	}
+	if _, err := otp.Validate(secret, "12345", now); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("code with invalid length should be rejected, err: %v", err)
+	}
+}
+
+func Test_ProvisioningURI(t *testing.T) {
+	uri := New().ProvisioningURI("whereiseveryone", "john", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/whereiseveryone:john?") ||
+		!strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=whereiseveryone") {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+}